@@ -0,0 +1,213 @@
+package goPon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// DefaultProvisionInterval is how often an AutoProvisioner polls the Blacklist unless changed
+const DefaultProvisionInterval = 30 * time.Second
+
+// kinds of ProvisionEvent
+const (
+	EventProvisioned = "provisioned" // registered on the port it appeared on, with its services
+	EventFailed      = "failed"      // on the auth list but could not be registered, tried again next poll
+	EventUnknownSn   = "unknown"     // on the Blacklist but not on the auth list
+	EventMisplaced   = "misplaced"   // already registered on an interface of another port
+	EventPassword    = "password"    // presents another password than the one on the auth list
+)
+
+// ProvisionEvent is raised by an AutoProvisioner for each Onu it acts on or cannot act on
+type ProvisionEvent struct {
+	Time         time.Time
+	Kind         string
+	SerialNumber string
+	Port         string   // Olt interface (0/x) the Onu appeared on
+	Interface    string   // Onu interface (0/x/y) it was registered on, or is registered on when misplaced
+	Services     []string // Service Profiles bound when provisioned
+	Err          error
+}
+
+func (e *ProvisionEvent) String() string {
+	s := fmt.Sprintf("%s %s %s on %s", e.Time.Format(time.RFC3339), e.Kind, e.SerialNumber, e.Port)
+	if e.Interface != "" {
+		s += fmt.Sprintf(" as %s", e.Interface)
+	}
+	if len(e.Services) > 0 {
+		s += fmt.Sprintf(" [%s]", strings.Join(e.Services, ", "))
+	}
+	if e.Err != nil {
+		s += fmt.Sprintf(": %v", e.Err)
+	}
+	return s
+}
+
+// AutoProvisioner watches the Onu Blacklist and registers each Serial Number of the auth list on the port it appears on,
+// applying the Service Profiles listed for it, so an Onu can be plugged in without anyone calling in
+type AutoProvisioner struct {
+	AuthFile string                // auth list in the format read by LoadOnuAuthList
+	Interval time.Duration         // time between polls of the Blacklist
+	OnEvent  func(*ProvisionEvent) // called with each event as it is raised, if set; every event is also logged
+	olt      *LumiaOlt
+	reported map[string]string // Serial Number to the kind of event already raised while it stays on the Blacklist
+}
+
+// NewAutoProvisioner returns an AutoProvisioner for l.Host reading the auth list at authFile
+func (l *LumiaOlt) NewAutoProvisioner(authFile string) *AutoProvisioner {
+	return &AutoProvisioner{
+		AuthFile: authFile,
+		Interval: DefaultProvisionInterval,
+		olt:      l,
+		reported: make(map[string]string),
+	}
+}
+
+// Run loads the current registry and the auth list, then polls the Blacklist every Interval until ctx is done.
+// A failed poll is logged and tried again at the next interval
+func (p *AutoProvisioner) Run(ctx context.Context) error {
+	l := p.olt
+	// the configured Onu first, so the interfaces they use are not handed out
+	err := l.UpdateOnuRegistryCtx(ctx)
+	if err != nil {
+		return err
+	}
+	err = l.LoadOnuAuthList(p.AuthFile)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		_, err = p.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("autoProvision: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the Blacklist once and registers every Onu of the auth list on it, returning the events raised.
+// Unknown and misplaced Onu are raised once while they stay on the Blacklist; failures are raised on every poll.
+// The requests of the poll are bounded by ctx
+func (p *AutoProvisioner) Poll(ctx context.Context) ([]*ProvisionEvent, error) {
+	l := p.olt
+	obll, err := l.GetOnuBlacklistCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var events []*ProvisionEvent
+	present := make(map[string]bool)
+	batch := l.NewRegistrationBatch()
+	var pending []*OnuRegister
+	var found []*OnuBlacklist
+	queued := make(map[*OnuRegister]bool)
+	for _, e := range obll.Entry {
+		present[e.SerialNumber] = true
+		onuReg, err := l.MatchBlacklistEntry(e)
+		switch {
+		case errors.Is(err, ErrPasswordMismatch):
+			events = append(events, p.once(&ProvisionEvent{Kind: EventPassword, SerialNumber: e.SerialNumber, Port: e.IfName, Err: err})...)
+			continue
+		case err != nil:
+			events = append(events, p.once(&ProvisionEvent{Kind: EventUnknownSn, SerialNumber: e.SerialNumber, Port: e.IfName})...)
+			continue
+		case queued[onuReg]:
+			// a second Onu presenting the same registration ID waits for the first to be registered
+			continue
+		}
+		intf := l.registerInterface(onuReg)
+		if intf != "" {
+			// registered on this port by an earlier poll, the Blacklist has not caught up yet
+			if !strings.HasPrefix(intf, e.IfName+"/") {
+				events = append(events, p.once(&ProvisionEvent{Kind: EventMisplaced, SerialNumber: e.SerialNumber, Port: e.IfName, Interface: intf})...)
+			}
+			continue
+		}
+		// an Onu matched by password only is configured by password only, as the installer intended
+		batch.Add(NewOnuConfigWithPassword(onuReg.SerialNumber, onuReg.Password, l.NextAvailableOnuInterface(e.IfName)), onuReg.Services)
+		pending = append(pending, onuReg)
+		found = append(found, e)
+		queued[onuReg] = true
+	}
+	// forget the one-time events of Onu that left the Blacklist, so they are raised again if they return
+	for sn := range p.reported {
+		if !present[sn] {
+			delete(p.reported, sn)
+		}
+	}
+	if len(pending) == 0 {
+		return events, nil
+	}
+	report, err := batch.RunCtx(ctx)
+	if report == nil {
+		for _, reg := range batch.queue {
+			l.ReleaseOnuInterface(reg.ocfg.IfName)
+		}
+		return events, err
+	}
+	var saveErr error
+	for i, r := range report.Entry {
+		ev := &ProvisionEvent{SerialNumber: found[i].SerialNumber, Port: found[i].IfName, Interface: r.Interface}
+		if r.Status == RegistrationDone {
+			if err := l.setRegisterInterface(pending[i], r.Interface); err != nil && saveErr == nil {
+				saveErr = err
+			}
+			ev.Kind = EventProvisioned
+			ev.Services = r.Services
+		} else {
+			ev.Kind = EventFailed
+			ev.Err = r.Err
+			// an interface configured by someone else stays held, so the next poll tries another
+			if !errors.Is(r.Err, ErrExists) {
+				l.ReleaseOnuInterface(r.Interface)
+			}
+		}
+		events = append(events, p.raise(ev))
+	}
+	return events, saveErr
+}
+
+// once raises the event unless one of the same kind was already raised for the Serial Number
+func (p *AutoProvisioner) once(ev *ProvisionEvent) []*ProvisionEvent {
+	if p.reported[ev.SerialNumber] == ev.Kind {
+		return nil
+	}
+	p.reported[ev.SerialNumber] = ev.Kind
+	return []*ProvisionEvent{p.raise(ev)}
+}
+
+// raise stamps, logs and delivers an event
+func (p *AutoProvisioner) raise(ev *ProvisionEvent) *ProvisionEvent {
+	ev.Time = time.Now()
+	log.Printf("autoProvision: %v\n", ev)
+	if p.OnEvent != nil {
+		p.OnEvent(ev)
+	}
+	return ev
+}
+
+// registerInterface returns the Interface of an OnuRegister of the Registration index
+func (l *LumiaOlt) registerInterface(onuReg *OnuRegister) string {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return onuReg.Interface
+}
+
+// setRegisterInterface records the interface an OnuRegister of the Registration index was configured on,
+// which then counts as used in place of the interface held by NextAvailableOnuInterface
+func (l *LumiaOlt) setRegisterInterface(onuReg *OnuRegister, intf string) error {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg.Interface = intf
+	onuReg.activate()
+	delete(l.reserved, intf)
+	return l.saveRegistry()
+}
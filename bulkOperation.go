@@ -0,0 +1,129 @@
+package goPon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultConcurrency is the number of requests a bulk operation keeps in flight unless changed on the LumiaOlt
+const DefaultConcurrency = 4
+
+// BulkProgress is called as each item of a bulk operation completes, with the count done so far out of total.
+// Calls are made one at a time, so the callback needs no locking of its own
+type BulkProgress func(done, total int, item string, err error)
+
+// BulkItemError is the failure of a single item of a bulk operation
+type BulkItemError struct {
+	Item string
+	Err  error
+}
+
+// BulkError collects the failures of a bulk operation in the order the items were supplied
+type BulkError struct {
+	Total int
+	Entry []*BulkItemError
+}
+
+func (e *BulkError) Error() string {
+	var list []string
+	for _, f := range e.Entry {
+		list = append(list, fmt.Sprintf("%s: %v", f.Item, f.Err))
+	}
+	return fmt.Sprintf("%d/%d failed: %s", len(e.Entry), e.Total, strings.Join(list, "; "))
+}
+
+// runBulk calls fn for each item on at most l.Concurrency goroutines, reporting each completion to l.Progress.
+// Once ctx is done the remaining items fail with its error without calling fn.
+// The failures are returned together as a *BulkError, or nil if every item succeeded
+func (l *LumiaOlt) runBulk(ctx context.Context, items []string, fn func(i int, item string) error) error {
+	return runConcurrent(ctx, l.Concurrency, l.Progress, items, fn)
+}
+
+// runConcurrent calls fn for each item on at most the given number of goroutines, reporting each completion to progress if set.
+// Once ctx is done the remaining items fail with its error without calling fn.
+// The failures are returned together as a *BulkError, or nil if every item succeeded
+func runConcurrent(ctx context.Context, workers int, progress BulkProgress, items []string, fn func(i int, item string) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(items) {
+		workers = len(items)
+	}
+	errs := make([]error, len(items))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var done int
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				err := ctx.Err()
+				if err == nil {
+					err = fn(i, items[i])
+				}
+				errs[i] = err
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, len(items), items[i], err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	bulkErr := &BulkError{Total: len(items)}
+	for i, err := range errs {
+		if err != nil {
+			bulkErr.Entry = append(bulkErr.Entry, &BulkItemError{Item: items[i], Err: err})
+		}
+	}
+	if len(bulkErr.Entry) > 0 {
+		return bulkErr
+	}
+	return nil
+}
+
+// AddServiceToMultipleOnu applies one Service Profile to each of the supplied OnuRegister objects concurrently.
+// Failures do not stop the other Onu and are returned together as a *BulkError
+func (l *LumiaOlt) AddServiceToMultipleOnu(sp string, onuRegs []*OnuRegister) error {
+	return l.AddServiceToMultipleOnuCtx(context.Background(), sp, onuRegs)
+}
+
+// AddServiceToMultipleOnuCtx is AddServiceToMultipleOnu with its requests bounded by ctx
+func (l *LumiaOlt) AddServiceToMultipleOnuCtx(ctx context.Context, sp string, onuRegs []*OnuRegister) error {
+	return l.runBulk(ctx, registerSerialNumbers(onuRegs), func(i int, _ string) error {
+		return l.PostOnuProfileCtx(ctx, NewOnuProfile(onuRegs[i].Interface, sp))
+	})
+}
+
+// RemoveServiceFromMultipleOnu removes one Service Profile from each of the supplied OnuRegister objects concurrently.
+// Failures do not stop the other Onu and are returned together as a *BulkError
+func (l *LumiaOlt) RemoveServiceFromMultipleOnu(sp string, onuRegs []*OnuRegister) error {
+	return l.RemoveServiceFromMultipleOnuCtx(context.Background(), sp, onuRegs)
+}
+
+// RemoveServiceFromMultipleOnuCtx is RemoveServiceFromMultipleOnu with its requests bounded by ctx
+func (l *LumiaOlt) RemoveServiceFromMultipleOnuCtx(ctx context.Context, sp string, onuRegs []*OnuRegister) error {
+	return l.runBulk(ctx, registerSerialNumbers(onuRegs), func(i int, _ string) error {
+		return l.RemoveOnuProfileUsageCtx(ctx, onuRegs[i].Interface, sp)
+	})
+}
+
+// registerSerialNumbers names the items of a bulk operation on OnuRegister objects
+func registerSerialNumbers(onuRegs []*OnuRegister) []string {
+	list := make([]string, len(onuRegs))
+	for i, o := range onuRegs {
+		list[i] = o.SerialNumber
+	}
+	return list
+}
@@ -0,0 +1,293 @@
+package goPon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// kinds of BulkAction
+const (
+	BulkDeauthorize   = "deauthorize"
+	BulkAuthorize     = "authorize"
+	BulkAddService    = "add service"
+	BulkRemoveService = "remove service"
+)
+
+// OnuFilter selects Onu of the Registration index; every field that is set must match
+type OnuFilter struct {
+	Port     string // Olt interface (0/x) the Onu is configured on
+	SnPrefix string // start of the Serial Number, such as a vendor ID
+	Service  string // Service Profile currently applied to the Onu
+}
+
+// IsEmpty reports whether the filter would match every Onu
+func (f OnuFilter) IsEmpty() bool {
+	return f.Port == "" && f.SnPrefix == "" && f.Service == ""
+}
+
+// Match reports whether the OnuRegister passes the filter
+func (f OnuFilter) Match(onuReg *OnuRegister) bool {
+	if f.Port != "" && !strings.HasPrefix(onuReg.Interface, f.Port+"/") {
+		return false
+	}
+	if f.SnPrefix != "" && !strings.HasPrefix(onuReg.SerialNumber, f.SnPrefix) {
+		return false
+	}
+	if f.Service != "" && !hasService(onuReg, f.Service) {
+		return false
+	}
+	return true
+}
+
+func hasService(onuReg *OnuRegister, sp string) bool {
+	for _, s := range onuReg.Services {
+		if s == sp {
+			return true
+		}
+	}
+	return false
+}
+
+// BulkAction is one change of a BulkPlan
+type BulkAction struct {
+	Action       string
+	SerialNumber string
+	Interface    string   // Onu interface (0/x/y), or the Olt interface (0/x) to allocate one on when authorizing
+	Services     []string // Service Profiles added, removed or applied with the authorization
+}
+
+// BulkPlan lists the changes of a per-port bulk operation so they can be previewed before ApplyBulk makes them
+type BulkPlan struct {
+	Entry []*BulkAction
+}
+
+// IsEmpty reports whether the plan has nothing to change
+func (p *BulkPlan) IsEmpty() bool {
+	return len(p.Entry) == 0
+}
+
+// PlanDeauthPort updates the registry and lists every Onu configured on the Olt interface (0/x) for deauthorization
+func (l *LumiaOlt) PlanDeauthPort(port string) (*BulkPlan, error) {
+	if port == "" {
+		return nil, ErrNotInput
+	}
+	regs, err := l.filterRegistry(OnuFilter{Port: port})
+	if err != nil {
+		return nil, err
+	}
+	plan := &BulkPlan{}
+	for _, o := range regs {
+		plan.Entry = append(plan.Entry, &BulkAction{Action: BulkDeauthorize, SerialNumber: o.SerialNumber, Interface: o.Interface, Services: o.Services})
+	}
+	return plan, nil
+}
+
+// PlanAuthorizePort lists every Onu on the Blacklist of the Olt interface (0/x) whose Serial Number is not known
+// for authorization with the supplied services, whether or not it is on the Authorized List.
+// Onu blacklisted for another cause are configured elsewhere and left out
+func (l *LumiaOlt) PlanAuthorizePort(port string, services []string) (*BulkPlan, error) {
+	if port == "" {
+		return nil, ErrNotInput
+	}
+	obll, err := l.GetOnuBlacklist()
+	if err != nil {
+		return nil, err
+	}
+	plan := &BulkPlan{}
+	for _, e := range obll.Entry {
+		if e.IfName != port || e.Cause != BlCauseSnNotKnown {
+			continue
+		}
+		plan.Entry = append(plan.Entry, &BulkAction{Action: BulkAuthorize, SerialNumber: e.SerialNumber, Interface: port, Services: services})
+	}
+	return plan, nil
+}
+
+// PlanServiceChange updates the registry and lists every Onu matching the filter that the Service Profile would be added to,
+// or removed from when add is false. Onu that already have it, or do not have it to remove, are left out
+func (l *LumiaOlt) PlanServiceChange(f OnuFilter, sp string, add bool) (*BulkPlan, error) {
+	if sp == "" || f.IsEmpty() {
+		return nil, ErrNotInput
+	}
+	regs, err := l.filterRegistry(f)
+	if err != nil {
+		return nil, err
+	}
+	action := BulkRemoveService
+	if add {
+		action = BulkAddService
+	}
+	plan := &BulkPlan{}
+	for _, o := range regs {
+		if hasService(o, sp) == add {
+			continue
+		}
+		plan.Entry = append(plan.Entry, &BulkAction{Action: action, SerialNumber: o.SerialNumber, Interface: o.Interface, Services: []string{sp}})
+	}
+	return plan, nil
+}
+
+// filterRegistry updates the registry and returns copies of the configured OnuRegister matching the filter
+func (l *LumiaOlt) filterRegistry(f OnuFilter) ([]*OnuRegister, error) {
+	err := l.UpdateOnuRegistry()
+	if err != nil {
+		return nil, err
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	var list []*OnuRegister
+	for _, o := range l.Registration {
+		if o.Interface != "" && f.Match(o) {
+			c := *o
+			c.Services = append([]string(nil), o.Services...)
+			list = append(list, &c)
+		}
+	}
+	return list, nil
+}
+
+// ApplyBulk makes the changes of a BulkPlan, l.Concurrency at a time. Each Onu to authorize is added to the Authorized List
+// and registered on the next available Onu Subinterface of its port with its services, or not at all.
+// Failures do not stop the other Onu and are returned together as a *BulkError
+func (l *LumiaOlt) ApplyBulk(p *BulkPlan) error {
+	return l.ApplyBulkCtx(context.Background(), p)
+}
+
+// ApplyBulkCtx is ApplyBulk with its requests bounded by ctx
+func (l *LumiaOlt) ApplyBulkCtx(ctx context.Context, p *BulkPlan) error {
+	var authorize, other []*BulkAction
+	for _, a := range p.Entry {
+		if a.Action == BulkAuthorize {
+			authorize = append(authorize, a)
+		} else {
+			other = append(other, a)
+		}
+	}
+	bulkErr := &BulkError{Total: len(p.Entry)}
+	err := l.runBulk(ctx, bulkActionItems(other), func(i int, _ string) error {
+		a := other[i]
+		switch a.Action {
+		case BulkDeauthorize:
+			// by interface, as an Onu authorized by password only has no Serial Number
+			return l.DeauthOnuByIntfCtx(ctx, a.Interface)
+		case BulkAddService:
+			return l.PostOnuProfileCtx(ctx, NewOnuProfile(a.Interface, a.Services[0]))
+		case BulkRemoveService:
+			return l.RemoveOnuProfileUsageCtx(ctx, a.Interface, a.Services[0])
+		}
+		return fmt.Errorf("%w: %s", ErrNotInput, a.Action)
+	})
+	var e *BulkError
+	if errors.As(err, &e) {
+		bulkErr.Entry = append(bulkErr.Entry, e.Entry...)
+	}
+	if len(authorize) > 0 {
+		err = l.authorizeFromBlacklist(ctx, authorize)
+		if errors.As(err, &e) {
+			bulkErr.Entry = append(bulkErr.Entry, e.Entry...)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(bulkErr.Entry) > 0 {
+		return bulkErr
+	}
+	return nil
+}
+
+// authorizeFromBlacklist registers each Onu of the authorize actions with a RegistrationBatch. A Serial Number added
+// to the Authorized List here is taken off again when its Onu is not registered
+func (l *LumiaOlt) authorizeFromBlacklist(ctx context.Context, actions []*BulkAction) error {
+	batch := l.NewRegistrationBatch()
+	undo := make(map[string]func() error)
+	for _, a := range actions {
+		err := l.AddSnToAuthList(a.SerialNumber)
+		switch {
+		case err == nil:
+			sn := a.SerialNumber
+			undo[sn] = func() error { return l.RemoveOnuAuthEntry(sn) }
+		case err != ErrExists:
+			return l.rollback(err, undoList(undo))
+		}
+		batch.Add(NewOnuConfig(a.SerialNumber, l.NextAvailableOnuInterface(a.Interface)), a.Services)
+	}
+	report, err := batch.RunCtx(ctx)
+	if report == nil {
+		for _, reg := range batch.queue {
+			l.ReleaseOnuInterface(reg.ocfg.IfName)
+		}
+		return l.rollback(err, undoList(undo))
+	}
+	for _, r := range report.Entry {
+		onuReg, rerr := l.GetOnuRegisterBySn(r.SerialNumber)
+		if r.Status == RegistrationDone && rerr == nil {
+			delete(undo, r.SerialNumber)
+			if serr := l.setRegisterInterface(onuReg, r.Interface); serr != nil && err == nil {
+				err = serr
+			}
+			continue
+		}
+		l.ReleaseOnuInterface(r.Interface)
+	}
+	if len(undo) > 0 {
+		return l.rollback(err, undoList(undo))
+	}
+	return err
+}
+
+// undoList orders the undo steps of a map by key
+func undoList(m map[string]func() error) []func() error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]func() error, len(keys))
+	for i, k := range keys {
+		list[i] = m[k]
+	}
+	return list
+}
+
+// bulkActionItems names the items of a bulk operation on BulkAction entries
+func bulkActionItems(actions []*BulkAction) []string {
+	list := make([]string, len(actions))
+	for i, a := range actions {
+		list[i] = a.SerialNumber
+	}
+	return list
+}
+
+var BulkPlanHeaders = []string{
+	"Action",
+	"Serial Number",
+	"Interface",
+	"Service Profiles",
+}
+
+// Tabwrite displays the changes of the plan in organized columns
+func (p *BulkPlan) Tabwrite() {
+	fmt.Println("|| Bulk Plan ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range BulkPlanHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range BulkPlanHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, a := range p.Entry {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t\n", a.Action, a.SerialNumber, a.Interface, strings.Join(a.Services, ", "))
+	}
+	for _, v := range BulkPlanHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
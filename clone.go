@@ -0,0 +1,196 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+)
+
+// kinds of CloneResult
+const (
+	CloneCreated = "created"
+	CloneRenamed = "renamed"
+	CloneReused  = "reused"
+)
+
+// CloneResult records where a profile of the source OLT ended up on the destination
+type CloneResult struct {
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	DstName string `json:"dstName"`
+	Status  string `json:"status"`
+}
+
+// CloneReport lists the profiles handled by CloneProfileTree, sub-profiles first and the Service Profile last
+type CloneReport struct {
+	Entry []*CloneResult
+}
+
+// CloneProfileTree copies a Service Profile and every sub-profile it references from src to dst.
+// A profile already on dst with the same name and settings is reused; one with the same name but different settings
+// is left alone and the copy is created under the first free name with a _1, _2, ... suffix, with the Service Profile
+// pointed at it. If a post fails the profiles created so far are deleted again
+func CloneProfileTree(src, dst *LumiaOlt, serviceProfileName string) (*CloneReport, error) {
+	return CloneProfileTreeCtx(context.Background(), src, dst, serviceProfileName)
+}
+
+// CloneProfileTreeCtx is CloneProfileTree with its requests bounded by ctx. The profiles created are deleted again even once ctx is done
+func CloneProfileTreeCtx(ctx context.Context, src, dst *LumiaOlt, serviceProfileName string) (*CloneReport, error) {
+	if serviceProfileName == "" {
+		return nil, ErrNotInput
+	}
+	srcTree, err := src.GetAllProfilesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstTree, err := dst.GetAllProfilesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	spEntry, ok := keyEntries(srcTree.tableEntries(serviceProfiles), tableKeys[serviceProfiles])[serviceProfileName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotExists, serviceProfiles, serviceProfileName)
+	}
+	sp := spEntry.Interface().(ServiceProfile)
+
+	report := &CloneReport{}
+	var undo []func() error
+	for _, r := range subProfileRefs {
+		name := reflect.ValueOf(sp).FieldByName(r.field).String()
+		if name == "" {
+			continue
+		}
+		result, err := dst.cloneProfile(ctx, r.table, name, srcTree, dstTree, &undo)
+		if err != nil {
+			return nil, dst.rollback(err, undo)
+		}
+		report.Entry = append(report.Entry, result)
+		reflect.ValueOf(&sp).Elem().FieldByName(r.field).SetString(result.DstName)
+	}
+	// the Service Profile is compared with its references already pointing at the names used on dst
+	single := NewIskratelMsan()
+	single.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry = []ServiceProfile{sp}
+	result, err := dst.cloneProfile(ctx, serviceProfiles, serviceProfileName, single, dstTree, &undo)
+	if err != nil {
+		return nil, dst.rollback(err, undo)
+	}
+	report.Entry = append(report.Entry, result)
+	return report, nil
+}
+
+// cloneProfile posts the named profile of srcTree to l.Host unless dstTree already holds an identical one under the name
+// or one of its suffixed names, renaming it if the name is taken, and adds the delete of anything created to undo
+func (l *LumiaOlt) cloneProfile(ctx context.Context, table, name string, srcTree, dstTree *IskratelMsan, undo *[]func() error) (*CloneResult, error) {
+	keys := tableKeys[table]
+	cur, ok := keyEntries(srcTree.tableEntries(table), keys)[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotExists, table, name)
+	}
+	p := reflect.New(cur.Type())
+	p.Elem().Set(cur)
+	p.Elem().FieldByName("Usage").SetInt(2)
+	if ovp, ok := p.Interface().(*OnuVlanProfile); ok {
+		ovp.Rules = srcTree.onuVlanRulesOf(name)
+	}
+	present := keyEntries(dstTree.tableEntries(table), keys)
+	result := &CloneResult{Table: table, Name: name, DstName: name, Status: CloneCreated}
+	np := p.Interface()
+	for n := 1; ; n++ {
+		existing, taken := present[result.DstName]
+		if !taken {
+			break
+		}
+		if sameProfile(existing, reflect.ValueOf(np).Elem(), dstTree.onuVlanRulesOf(result.DstName)) {
+			result.Status = CloneReused
+			return result, nil
+		}
+		result.Status = CloneRenamed
+		result.DstName = fmt.Sprintf("%s_%d", name, n)
+		var err error
+		np, err = copyProfile(p.Interface(), result.DstName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.Marshal(np)
+	if err != nil {
+		return nil, err
+	}
+	err = l.postProfile(ctx, table, result.DstName, data)
+	if err != nil {
+		return nil, fmt.Errorf("clone %s %s: %w", table, result.DstName, err)
+	}
+	*undo = append(*undo, func() error { return l.deleteProfile(context.Background(), table, result.DstName) })
+	// later lookups in dstTree see the new profile, so a second reference to it is reused
+	err = dstTree.appendEntry(table, reflect.ValueOf(np).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sameProfile reports whether a profile on the OLT holds the same settings as a profile to be posted,
+// ignoring Usage and including the rules of an OnuVlanProfile
+func sameProfile(existing, posted reflect.Value, existingRules *OnuVlanRuleList) bool {
+	for _, f := range diffFields(existing, posted) {
+		if f.Field != "Usage" {
+			return false
+		}
+	}
+	if ovp, ok := posted.Interface().(OnuVlanProfile); ok && ovp.Rules != nil {
+		return len(ruleChanges(existingRules, ovp.Rules)) == 0
+	}
+	return true
+}
+
+// appendEntry adds an entry to the table named by ep, along with the rules of an OnuVlanProfile
+func (im *IskratelMsan) appendEntry(ep string, e reflect.Value) error {
+	v := reflect.ValueOf(&im.ISKRATELMSANMIB.ISKRATELMSANMIB).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) != ep {
+			continue
+		}
+		slice := v.Field(i).Field(0)
+		slice.Set(reflect.Append(slice, e))
+		if ovp, ok := e.Interface().(OnuVlanProfile); ok && ovp.Rules != nil {
+			rules := &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry
+			for _, r := range ovp.Rules.Entry {
+				*rules = append(*rules, *r)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNotField, ep)
+}
+
+var CloneReportHeaders = []string{
+	"Table",
+	"Name",
+	"Destination Name",
+	"Status",
+}
+
+// Tabwrite displays where each cloned profile was placed in organized columns
+func (r *CloneReport) Tabwrite() {
+	fmt.Println("|| Clone Report ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range CloneReportHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range CloneReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, e := range r.Entry {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t\n", e.Table, e.Name, e.DstName, e.Status)
+	}
+	for _, v := range CloneReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"time"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/lindsaybb/goPon"
+	"github.com/lindsaybb/goPon/gopontest"
 )
 
 var (
@@ -18,34 +22,138 @@ var (
 	getWhitelist   = flag.Bool("gw", false, "Show the current ONU Whitelist on the OLT")
 	registerOne    = flag.Bool("ro", false, "Manually register one ONU to the OLT")
 	registerMany   = flag.Bool("rm", false, "Automatically Register many ONU to the system using a file [af]; SN must exist on Blacklist")
-	authFile       = flag.String("af", "authList.txt", "Path to file that contains list of Authorized ONU Serial Numbers and their Service Profiles")
+	authFile       = flag.String("af", "authList.txt", "Path to file that contains list of Authorized ONU Serial Numbers and their Service Profiles, with an optional pw=<password> column")
 	deregisterOne  = flag.Bool("do", false, "Manually Deregister one ONU from the OLT")
 	deregisterMany = flag.Bool("dm", false, "Automatically Deregister multiple ONU from the system using a file [df]")
 	deAuthFile     = flag.String("df", "deAuthList.txt", "Path to file that contains list of ONU Serial Numbers to Deauthorize")
+	replaceOne     = flag.Bool("xo", false, "Replace one failed ONU with a new Serial Number from the Blacklist, keeping its interface and service profiles")
+	moveOne        = flag.Bool("mo", false, "Move one ONU to another OLT port (0/x) or ONU interface (0/x/y), keeping its service profiles")
+	suspendOne     = flag.Bool("so", false, "Suspend one ONU, keeping its service profiles in the registry file [rg] to restore with [uo]")
+	resumeOne      = flag.Bool("uo", false, "Resume one ONU suspended with [so], restoring its service profiles")
+	walledGarden   = flag.String("wg", "", "Service profile bound to an ONU suspended by [so] in place of its own (default: disable the ONU instead)")
 	addOneSp       = flag.Bool("ap", false, "Manually Add one service profile to a registered ONU from a list of created Service Profiles")
 	remOneSp       = flag.Bool("rp", false, "Manually Remove one service profile from a registered ONU")
 	showSpDetails  = flag.Bool("sp", false, "View Detailed Information about Service Profiles")
+	username       = flag.String("u", "", "Username for the OLT login (default from GOPON_USERNAME or factory default)")
+	password       = flag.String("pw", "", "Password for the OLT login (default from GOPON_PASSWORD or factory default)")
+	credFile       = flag.String("cf", "", "Path to file that contains the OLT login as username=/password= lines")
+	caFile         = flag.String("ca", "", "Path to PEM bundle used to verify the OLT certificate (default: not verified)")
+	pinCert        = flag.String("pin", "", "SHA-256 fingerprint of the OLT certificate to pin (default: not verified)")
+	reqTimeout     = flag.Duration("t", goPon.DefaultRestconfTimeout, "Deadline for each request to the OLT")
+	regStore       = flag.String("rg", "", "Path to a registry file that keeps the ONU registry, with its subscriber details and states, between runs")
+	workers        = flag.Int("w", goPon.DefaultConcurrency, "Number of requests kept in flight by the bulk operations [rm] and [dm]")
+	snapSave       = flag.String("ss", "", "Save a snapshot of every profile, ONU config and service-port binding of the OLT to this path")
+	snapRestore    = flag.String("rs", "", "Restore a snapshot saved with [ss] onto the OLT, skipping entries that already exist")
+	stateFile      = flag.String("ds", "", "Path to a desired-state file of profiles; shows the plan to bring the OLT to it")
+	stateApply     = flag.Bool("da", false, "Apply the plan of the desired-state file [ds] after confirmation")
+	statePrune     = flag.Bool("dp", false, "Include deletes of profiles missing from the desired-state file [ds] in the plan")
+	stateExport    = flag.String("dx", "", "Export the profiles of the OLT to this path as a desired-state file")
+	checkProfiles  = flag.Bool("pg", false, "Check the profile references of the OLT for dangling names and orphaned sub-profiles")
+	deauthPort     = flag.String("xp", "", "Deauthorize every ONU on this OLT port (0/x)")
+	authPort       = flag.String("ab", "", "Authorize every unknown ONU on the Blacklist of this OLT port (0/x) with the services [bs]")
+	authServices   = flag.String("bs", "", "Comma-separated service profiles applied to each ONU authorized by [ab]")
+	addSpMany      = flag.String("sa", "", "Add this service profile to every ONU matching the filters [fp], [fn] and [fs]")
+	remSpMany      = flag.String("sr", "", "Remove this service profile from every ONU matching the filters [fp], [fn] and [fs]")
+	filterPort     = flag.String("fp", "", "Filter of [sa] and [sr]: OLT port (0/x) the ONU is on")
+	filterSn       = flag.String("fn", "", "Filter of [sa] and [sr]: prefix of the ONU Serial Number")
+	filterSp       = flag.String("fs", "", "Filter of [sa] and [sr]: service profile the ONU currently has")
+	dryRun         = flag.Bool("n", false, "Only show what [xp], [ab], [sa] and [sr] would change")
+	daemon         = flag.Bool("daemon", false, "Keep running and register each ONU of the auth list [af] as it appears on the Blacklist, until interrupted")
+	pollInterval   = flag.Duration("pi", goPon.DefaultProvisionInterval, "Interval between Blacklist polls of [daemon]")
+	optics         = flag.Bool("om", false, "Keep running and watch the optical levels of every ONU, logging threshold and degradation events, until interrupted")
+	opticsInterval = flag.Duration("oi", goPon.DefaultOpticalInterval, "Interval between optical readings of [om]")
+	opticsPort     = flag.String("op", "", "Only watch the ONU of this OLT port (0/x) with [om]")
+	opticsClass    = flag.String("oc", goPon.DefaultOpticalClass, "Optics class of the OLT ports watched by [om]: B+ or C+")
+	opticsDrop     = flag.Float64("od", goPon.DefaultDropDb, "Loss in dB against its baseline at which [om] reports an ONU degraded")
+	fleetFile      = flag.String("fleet", "", "Path to a Json inventory of OLTs; runs the fleet queries [find], [fbl] and [fsp] instead of targeting <olt_ip>")
+	fleetOlts      = flag.String("olt", "", "Comma-separated names of the OLTs of [fleet] to query (default: all)")
+	fleetSite      = flag.String("site", "", "Only query the OLTs of [fleet] at this site")
+	fleetTags      = flag.String("tag", "", "Comma-separated tags every OLT of [fleet] queried must have")
+	fleetFind      = flag.String("find", "", "Report this ONU Serial Number from the config, Blacklist and info tables of the OLT, or of every OLT of [fleet]")
+	fleetBlacklist = flag.Bool("fbl", false, "Show the ONU Blacklist of every OLT of [fleet]")
+	fleetSp        = flag.String("fsp", "", "Show which OLTs of [fleet] define or use this service profile")
+	simulate       = flag.Bool("sim", false, "Run against a local OLT simulator seeded with the demo profiles and the ONU in [af] on the Blacklist; <olt_ip> is not required")
 )
 
-// to add: deregister all from a specified port, authorize all from blacklist, indirect add/rem of service profiles in bulk
-
 const usage = "`goPon_cmd` [options] <olt_ip>"
 
 func main() {
 	flag.Parse()
 
-	if *helpFlag || flag.NArg() < 1 {
+	if *helpFlag || (flag.NArg() < 1 && !*simulate && *fleetFile == "") {
 		fmt.Println(usage)
 		flag.PrintDefaults()
 		return
 	}
+	if *fleetFile != "" {
+		err := queryFleet()
+		if err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
 	var err error
-	host := flag.Args()[0]
+	var host string
+	var sim *gopontest.Simulator
+	if *simulate {
+		sim, err = startSimulator()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer sim.Close()
+		host = sim.Host()
+		fmt.Printf("Simulator running on %s\n", host)
+	} else {
+		host = flag.Args()[0]
+	}
 	olt := goPon.NewLumiaOlt(host)
+	if *credFile != "" {
+		err = olt.LoadCredentialsFile(*credFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if *username != "" {
+		olt.Credentials.Username = *username
+	}
+	if *password != "" {
+		olt.Credentials.Password = *password
+	}
+	if sim != nil {
+		// the simulator accepts whichever login was chosen
+		sim.Credentials = olt.Credentials
+	}
+	olt.Client.Timeout = *reqTimeout
+	olt.Concurrency = *workers
+	olt.Progress = printProgress
+	olt.WalledGarden = *walledGarden
+	if *caFile != "" {
+		err = olt.Client.LoadCABundle(*caFile)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if *pinCert != "" {
+		err = olt.Client.PinCertificates(*pinCert)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
 	if !olt.HostIsReachable() {
 		fmt.Printf("Host %s is not reachable\n", host)
 		return
 	}
+	if *regStore != "" {
+		err = olt.OpenRegistryStore(goPon.NewFileRegistryStore(*regStore))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
 	if *getBlacklist {
 		fmt.Println(">> Get Blacklist Called [-gb]")
 		var obll *goPon.OnuBlacklistList
@@ -57,6 +165,21 @@ func main() {
 		obll.Tabwrite()
 		promptContinue()
 	}
+	if *fleetFind != "" {
+		fmt.Println(">> Find Called [-find]")
+		var orl *goPon.OnuReportList
+		orl, err = olt.FindOnu(sanitizeSnInput(*fleetFind))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(orl.Entry) < 1 {
+			fmt.Printf("%s not found on OLT!\n", *fleetFind)
+		} else {
+			orl.Tabwrite()
+		}
+		promptContinue()
+	}
 	if *getWhitelist {
 		fmt.Println(">> Get Whitelist Called [-gw]")
 		err = olt.UpdateOnuRegistry()
@@ -103,6 +226,38 @@ func main() {
 		}
 		promptContinue()
 	}
+	if *replaceOne {
+		fmt.Println(">> Replace One Called [-xo]")
+		err = replaceOnu(olt)
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *moveOne {
+		fmt.Println(">> Move One Called [-mo]")
+		err = moveOnu(olt)
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *suspendOne {
+		fmt.Println(">> Suspend One Called [-so]")
+		err = suspendOnu(olt, true)
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *resumeOne {
+		fmt.Println(">> Resume One Called [-uo]")
+		err = suspendOnu(olt, false)
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
 	if *addOneSp {
 		fmt.Println(">> Add One Service Profile Called [-ap]")
 		err = addServiceToOnu(olt)
@@ -127,6 +282,278 @@ func main() {
 		}
 		promptContinue()
 	}
+	if *snapSave != "" {
+		fmt.Println(">> Save Snapshot Called [-ss]")
+		var s *goPon.Snapshot
+		s, err = olt.SaveSnapshot(*snapSave)
+		if err != nil {
+			fmt.Printf("Error saving snapshot: %v\n", err)
+		} else {
+			fmt.Printf("Saved snapshot of %s taken %s to %s\n", s.Host, s.Timestamp.Format(time.RFC3339), *snapSave)
+		}
+		promptContinue()
+	}
+	if *checkProfiles {
+		fmt.Println(">> Check Profile References Called [-pg]")
+		err = checkProfileGraph(olt)
+		if err != nil {
+			fmt.Printf("Error checking profiles: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *stateExport != "" {
+		fmt.Println(">> Export Desired State Called [-dx]")
+		var im *goPon.IskratelMsan
+		im, err = olt.GetAllProfiles()
+		if err == nil {
+			err = goPon.DesiredStateFromTree(im).WriteFile(*stateExport)
+		}
+		if err != nil {
+			fmt.Printf("Error exporting desired state: %v\n", err)
+		} else {
+			fmt.Printf("Exported profiles to %s\n", *stateExport)
+		}
+		promptContinue()
+	}
+	if *stateFile != "" {
+		fmt.Println(">> Plan Desired State Called [-ds]")
+		err = planDesiredState(olt, *stateFile)
+		if err != nil {
+			fmt.Printf("Error applying desired state: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *snapRestore != "" {
+		fmt.Println(">> Restore Snapshot Called [-rs]")
+		err = restoreSnapshot(olt, *snapRestore)
+		if err != nil {
+			fmt.Printf("Error restoring snapshot: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *deauthPort != "" {
+		fmt.Println(">> Deauthorize Port Called [-xp]")
+		err = runBulkPlan(olt, func() (*goPon.BulkPlan, error) { return olt.PlanDeauthPort(*deauthPort) })
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *authPort != "" {
+		fmt.Println(">> Authorize Port from Blacklist Called [-ab]")
+		services := splitList(*authServices)
+		err = runBulkPlan(olt, func() (*goPon.BulkPlan, error) { return olt.PlanAuthorizePort(*authPort, services) })
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	filter := goPon.OnuFilter{Port: *filterPort, SnPrefix: *filterSn, Service: *filterSp}
+	if *addSpMany != "" {
+		fmt.Println(">> Add Service Profile to Many Called [-sa]")
+		err = runBulkPlan(olt, func() (*goPon.BulkPlan, error) { return olt.PlanServiceChange(filter, *addSpMany, true) })
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *remSpMany != "" {
+		fmt.Println(">> Remove Service Profile from Many Called [-sr]")
+		err = runBulkPlan(olt, func() (*goPon.BulkPlan, error) { return olt.PlanServiceChange(filter, *remSpMany, false) })
+		if err != nil {
+			fmt.Printf("Error running demo: %v\n", err)
+		}
+		promptContinue()
+	}
+	if *daemon {
+		fmt.Println(">> Auto-Provisioning Called [-daemon]")
+		err = autoProvision(olt)
+		if err != nil {
+			fmt.Printf("Error auto-provisioning: %v\n", err)
+		}
+	}
+	if *optics {
+		fmt.Println(">> Optical Monitor Called [-om]")
+		err = watchOptics(olt)
+		if err != nil {
+			fmt.Printf("Error monitoring optics: %v\n", err)
+		}
+	}
+}
+
+// runBulkPlan shows the plan of a bulk operation and, unless [n] is set, applies it after confirmation
+func runBulkPlan(olt *goPon.LumiaOlt, plan func() (*goPon.BulkPlan, error)) error {
+	p, err := plan()
+	if err != nil {
+		return err
+	}
+	if p.IsEmpty() {
+		fmt.Println("No ONU to change")
+		return nil
+	}
+	p.Tabwrite()
+	if *dryRun {
+		return nil
+	}
+	fmt.Printf(">> Apply these %d changes?\n", len(p.Entry))
+	promptContinue()
+	err = olt.ApplyBulk(p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d changes\n", len(p.Entry))
+	return nil
+}
+
+// autoProvision watches the Blacklist and registers the ONU of the auth list until interrupted; events are logged as they occur
+func autoProvision(olt *goPon.LumiaOlt) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	p := olt.NewAutoProvisioner(*authFile)
+	p.Interval = *pollInterval
+	fmt.Printf("Polling the Blacklist every %v, interrupt to stop\n", p.Interval)
+	err := p.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// watchOptics reads the optical levels of the ONU every [oi] and shows them, with the events logged as they are raised
+func watchOptics(olt *goPon.LumiaOlt) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	m := olt.NewOpticalMonitor()
+	m.Interval = *opticsInterval
+	m.Port = *opticsPort
+	m.DefaultClass = *opticsClass
+	m.DropDb = *opticsDrop
+	if _, ok := m.Classes[m.DefaultClass]; !ok {
+		return fmt.Errorf("%w: optics class %s", goPon.ErrNotInput, m.DefaultClass)
+	}
+	fmt.Printf("Reading optical levels every %v, interrupt to stop\n", m.Interval)
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		_, err := m.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println(err)
+		} else if err == nil {
+			m.Tabwrite()
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkProfileGraph lists the references to profiles that do not exist and the sub-profiles nothing uses
+func checkProfileGraph(olt *goPon.LumiaOlt) error {
+	g, err := olt.GetProfileGraph()
+	if err != nil {
+		return err
+	}
+	dangling := g.Dangling()
+	if len(dangling.Entry) < 1 {
+		fmt.Println("No dangling profile references")
+	} else {
+		dangling.Tabwrite()
+	}
+	orphans := g.Orphans()
+	if len(orphans) < 1 {
+		fmt.Println("No orphaned sub-profiles")
+	}
+	for _, n := range orphans {
+		fmt.Printf("Orphaned: %s %s\n", n.Table, n.Name)
+	}
+	return nil
+}
+
+// planDesiredState shows the plan for a desired-state file and applies it if requested with [da]
+func planDesiredState(olt *goPon.LumiaOlt, path string) error {
+	ds, err := goPon.LoadDesiredState(path)
+	if err != nil {
+		return err
+	}
+	plan, err := olt.PlanDesiredState(ds, *statePrune)
+	if err != nil {
+		return err
+	}
+	if plan.IsEmpty() {
+		fmt.Println("OLT already matches the desired state")
+		return nil
+	}
+	plan.Tabwrite()
+	if !*stateApply {
+		return nil
+	}
+	fmt.Println(">> Apply this plan?")
+	promptContinue()
+	err = olt.Apply(plan)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d changes\n", len(plan.Entry))
+	return nil
+}
+
+// restoreSnapshot loads a snapshot file and replays it onto the OLT, displaying the outcome of every entry
+func restoreSnapshot(olt *goPon.LumiaOlt, path string) error {
+	s, err := goPon.LoadSnapshot(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restoring snapshot of %s taken %s\n", s.Host, s.Timestamp.Format(time.RFC3339))
+	report, err := olt.RestoreSnapshot(s)
+	if report != nil {
+		report.Tabwrite()
+	}
+	return err
+}
+
+// startSimulator seeds a local OLT with the demo profiles and places every Serial Number of the auth file
+// on the Blacklist, alternating between ports 0/1 and 0/2
+func startSimulator() (*gopontest.Simulator, error) {
+	sim := gopontest.NewSimulator()
+	err := sim.SeedDemo()
+	if err != nil {
+		sim.Close()
+		return nil, err
+	}
+	f, err := os.Open(*authFile)
+	if err != nil {
+		// the simulator is still usable for manual registration
+		fmt.Println(err)
+		return sim, nil
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	var n int
+	for s.Scan() {
+		line := strings.Fields(s.Text())
+		if len(line) < 1 {
+			continue
+		}
+		var pw string
+		for _, f := range line {
+			if strings.HasPrefix(f, "pw=") {
+				pw = strings.TrimPrefix(f, "pw=")
+			}
+		}
+		sn := sanitizeSnInput(line[0])
+		if strings.HasPrefix(line[0], "pw=") {
+			// authorized by password only, any serial number will do
+			sn = fmt.Sprintf("ISKTA%07d", n)
+		}
+		if sn == "" {
+			continue
+		}
+		sim.AddOnu(fmt.Sprintf("0/%d", n%2+1), sn, pw)
+		n++
+	}
+	return sim, nil
 }
 
 func manuallyRegisterOnu(olt *goPon.LumiaOlt) error {
@@ -170,6 +597,8 @@ func manuallyRegisterOnu(olt *goPon.LumiaOlt) error {
 	// If it does, it executes a PATCH request updating the existing Provisioned ONUs with the new ONU Config
 	err = olt.AuthorizeOnu(ocfg)
 	if err != nil {
+		// hand the interface back so the next registration can use it
+		olt.ReleaseOnuInterface(intf)
 		return err
 	}
 	// perform GET request on OLT WhiteList and update app's db of currently provisioned ONU
@@ -209,6 +638,75 @@ func manuallyRegisterOnu(olt *goPon.LumiaOlt) error {
 	return nil
 }
 
+// printProgress shows each completed item of a bulk operation
+func printProgress(done, total int, item string, err error) {
+	if err != nil {
+		fmt.Printf("[%d/%d] %s: %v\n", done, total, item, err)
+		return
+	}
+	fmt.Printf("[%d/%d] %s\n", done, total, item)
+}
+
+// splitList returns the comma-separated values of a flag, or none if it is empty
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// queryFleet loads the inventory [fleet] and runs each fleet query requested on the OLTs passing the filters.
+// OLTs that cannot be read are listed after the results of the others
+func queryFleet() error {
+	fleet, err := goPon.LoadFleet(*fleetFile)
+	if err != nil {
+		return err
+	}
+	for _, fo := range fleet.Entry {
+		fo.Olt.Client.Timeout = *reqTimeout
+		// requests of many OLTs at once would interleave
+		fo.Olt.Client.Verbose = false
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	filter := goPon.FleetFilter{Names: splitList(*fleetOlts), Site: *fleetSite, Tags: splitList(*fleetTags)}
+	fmt.Printf("Querying %d of %d OLTs\n", len(fleet.Select(filter)), len(fleet.Entry))
+	if *fleetFind != "" {
+		fmt.Println(">> Fleet Find Called [-find]")
+		orl, err := fleet.FindOnu(ctx, filter, sanitizeSnInput(*fleetFind))
+		if orl != nil {
+			orl.Tabwrite()
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	if *fleetBlacklist {
+		fmt.Println(">> Fleet Blacklist Called [-fbl]")
+		fbl, err := fleet.GetOnuBlacklist(ctx, filter)
+		if fbl != nil {
+			fbl.Tabwrite()
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	if *fleetSp != "" {
+		fmt.Println(">> Fleet Service Profile Usage Called [-fsp]")
+		fpl, err := fleet.GetServiceProfileUsage(ctx, filter, *fleetSp)
+		if fpl != nil {
+			fpl.Tabwrite()
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	return nil
+}
+
 func registerOnuFromFile(olt *goPon.LumiaOlt) error {
 	now := time.Now()
 	fmt.Println("Starting the Timer")
@@ -242,47 +740,31 @@ func registerOnuFromFile(olt *goPon.LumiaOlt) error {
 
 	fmt.Println("\nCreating an Onu Config for each Onu on the Blacklist to attempt to Register")
 
-	var ocfg *goPon.OnuConfig
+	// each Onu is authorized together with its services, or not at all
+	batch := olt.NewRegistrationBatch()
 	for _, e := range obll.Entry {
 		// since the Olt Registry has the new SerialNumbers, this check will include them
-		if olt.ValidateSn(e.SerialNumber) {
-			onuReg, err := olt.GetOnuRegisterBySn(e.SerialNumber)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
-			var intf string
-			if onuReg.Interface == "" {
-				onuReg = olt.NextAvailableOnuInterfaceUpdateRegister(e.IfName, onuReg)
-			}
-			intf = onuReg.Interface
-			ocfg = goPon.NewOnuConfig(e.SerialNumber, intf)
-			err = olt.AuthorizeOnu(ocfg)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
-			if len(onuReg.Services) < 1 {
-				continue
-			}
-			spl, err := olt.GetServiceProfiles()
-			if err != nil {
-				return err
-			}
-			for _, sp := range onuReg.Services {
-				if spl.ProfileExists(sp) {
-					// created new ONU Profile by combining registered interface with service profile name
-					newProfile := goPon.NewOnuProfile(ocfg.IfName, sp)
-					// error will be checked if the server accepts the patch
-					err = olt.PostOnuProfile(newProfile)
-					if err != nil {
-						return err
-					}
-				}
-			}
-		} else {
+		// along with the registration IDs of ONU authorized by password only
+		onuReg, err := olt.MatchBlacklistEntry(e)
+		if errors.Is(err, goPon.ErrPasswordMismatch) {
+			fmt.Printf("Serial Number [%s] does not present the password on the Authorized List. Skipping Registration.\n", e.SerialNumber)
+			continue
+		}
+		if err != nil {
 			fmt.Printf("Serial Number [%s] is on Blacklist but not on Authorized List. Skipping Registration.\n", e.SerialNumber)
+			continue
 		}
+		if onuReg.Interface == "" {
+			onuReg = olt.NextAvailableOnuInterfaceUpdateRegister(e.IfName, onuReg)
+		}
+		batch.Add(goPon.NewOnuConfigWithPassword(onuReg.SerialNumber, onuReg.Password, onuReg.Interface), onuReg.Services)
+	}
+	report, err := batch.Run()
+	if report != nil {
+		report.Tabwrite()
+	}
+	if err != nil {
+		fmt.Println(err)
 	}
 
 	nobll, err := olt.GetOnuBlacklist()
@@ -338,7 +820,7 @@ func manuallyDeregisterOnu(olt *goPon.LumiaOlt) error {
 func deRegisterOnuFromFile(olt *goPon.LumiaOlt) error {
 	now := time.Now()
 	fmt.Println("Starting the Timer")
-	
+
 	var err error
 	// this function updates the list of currently connected devices with two GET requests
 	err = olt.UpdateOnuRegistry()
@@ -352,14 +834,19 @@ func deRegisterOnuFromFile(olt *goPon.LumiaOlt) error {
 	// Deauthorized any Onu Serial Numbers that appear in the file
 	err = olt.DeauthOnuBySnList(*deAuthFile)
 	if err != nil {
-		return err
+		// some Onu failing does not stop the rest from being followed up
+		var bulkErr *goPon.BulkError
+		if !errors.As(err, &bulkErr) {
+			return err
+		}
+		fmt.Println(err)
 	}
 
-//	err = olt.UpdateOnuRegistry()
-//	if err != nil {
-//		return err
-//	}
-//	olt.TabwriteRegistry()
+	//	err = olt.UpdateOnuRegistry()
+	//	if err != nil {
+	//		return err
+	//	}
+	//	olt.TabwriteRegistry()
 	// Blacklist might not show the devices yet, takes about 1 min to update
 	var obll *goPon.OnuBlacklistList
 	obll, err = olt.GetOnuBlacklist()
@@ -381,7 +868,7 @@ func deRegisterOnuFromFile(olt *goPon.LumiaOlt) error {
 	obll.Tabwrite()
 
 	fmt.Printf("Elapsed operation time: %v\n", time.Since(now))
-	
+
 	return nil
 }
 
@@ -431,6 +918,110 @@ func addServiceToOnu(olt *goPon.LumiaOlt) error {
 	return nil
 }
 
+func replaceOnu(olt *goPon.LumiaOlt) error {
+	// first show the current device provisioning and the devices waiting to take over
+	err := olt.UpdateOnuRegistry()
+	if err != nil {
+		return err
+	}
+	olt.TabwriteRegistry()
+	obll, err := olt.GetOnuBlacklist()
+	if err != nil {
+		return err
+	}
+	obll.Tabwrite()
+	reader := bufio.NewReaderSize(os.Stdin, 1024*1024)
+	fmt.Println(">> Provide Serial Number of the ONU to Replace:")
+	oldSn := sanitizeSnInput(readFromStdin(reader))
+	if oldSn == "" {
+		return goPon.ErrNotInput
+	}
+	fmt.Println(">> Provide Serial Number of the new ONU from the Blacklist:")
+	newSn := sanitizeSnInput(readFromStdin(reader))
+	if newSn == "" {
+		return goPon.ErrNotInput
+	}
+	err = olt.ReplaceOnu(oldSn, newSn)
+	if err != nil {
+		return err
+	}
+	// perform GET request on OLT WhiteList and update app's db of currently provisioned ONU
+	err = olt.UpdateOnuRegistry()
+	if err != nil {
+		return err
+	}
+	olt.TabwriteRegistry()
+
+	return nil
+}
+
+// suspendOnu suspends the ONU chosen from the registry, or resumes it when suspend is false
+func suspendOnu(olt *goPon.LumiaOlt, suspend bool) error {
+	// the services of a suspended ONU are only kept in the registry file
+	if suspend && *regStore == "" {
+		return fmt.Errorf("%w: [so] needs a registry file [rg]", goPon.ErrNotInput)
+	}
+	// first show the current device provisioning and states
+	err := olt.UpdateOnuRegistry()
+	if err != nil {
+		return err
+	}
+	olt.TabwriteRegistry()
+	reader := bufio.NewReaderSize(os.Stdin, 1024*1024)
+	if suspend {
+		fmt.Println(">> Provide Serial Number of the ONU to Suspend:")
+	} else {
+		fmt.Println(">> Provide Serial Number of the ONU to Resume:")
+	}
+	sn := sanitizeSnInput(readFromStdin(reader))
+	if sn == "" {
+		return goPon.ErrNotInput
+	}
+	if suspend {
+		err = olt.SuspendOnu(sn)
+	} else {
+		err = olt.ResumeOnu(sn)
+	}
+	if err != nil {
+		return err
+	}
+	err = olt.UpdateOnuRegistry()
+	if err != nil {
+		return err
+	}
+	olt.TabwriteRegistry()
+
+	return nil
+}
+
+func moveOnu(olt *goPon.LumiaOlt) error {
+	// first show the current device provisioning
+	err := olt.UpdateOnuRegistry()
+	if err != nil {
+		return err
+	}
+	olt.TabwriteRegistry()
+	reader := bufio.NewReaderSize(os.Stdin, 1024*1024)
+	fmt.Println(">> Provide Serial Number of the ONU to Move:")
+	sn := sanitizeSnInput(readFromStdin(reader))
+	if sn == "" {
+		return goPon.ErrNotInput
+	}
+	fmt.Println(">> Provide OLT Port (0/x) or ONU Interface (0/x/y) to Move it to:")
+	target := sanitizeInput(readFromStdin(reader))
+	if target == "" {
+		return goPon.ErrNotInput
+	}
+	intf, err := olt.MoveOnu(sn, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s to %s\n", sn, intf)
+	olt.TabwriteRegistry()
+
+	return nil
+}
+
 func removeServiceFromOnu(olt *goPon.LumiaOlt) error {
 	// first show the current device provisioning
 	err := olt.UpdateOnuRegistry()
@@ -0,0 +1,101 @@
+package goPon
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultUsername = "admin"
+	defaultPassword = "admin"
+	// environment variables consulted by CredentialsFromEnv
+	EnvUsername        = "GOPON_USERNAME"
+	EnvPassword        = "GOPON_PASSWORD"
+	EnvCredentialsFile = "GOPON_CREDENTIALS"
+)
+
+// Credentials holds the login used for both the Restconf session cookie and the Ftp client
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// NewCredentials returns a Credentials object for the supplied username and password
+func NewCredentials(username, password string) *Credentials {
+	c := &Credentials{
+		Username: username,
+		Password: password,
+	}
+	return c
+}
+
+// DefaultCredentials returns the factory default login of the OLT
+func DefaultCredentials() *Credentials {
+	return NewCredentials(defaultUsername, defaultPassword)
+}
+
+// CredentialsFromEnv builds Credentials from the GOPON_CREDENTIALS file if set, otherwise from
+// GOPON_USERNAME and GOPON_PASSWORD; any value not supplied falls back to the factory default
+func CredentialsFromEnv() (*Credentials, error) {
+	if path := os.Getenv(EnvCredentialsFile); path != "" {
+		return LoadCredentialsFile(path)
+	}
+	c := DefaultCredentials()
+	if un := os.Getenv(EnvUsername); un != "" {
+		c.Username = un
+	}
+	if pw := os.Getenv(EnvPassword); pw != "" {
+		c.Password = pw
+	}
+	return c, nil
+}
+
+// LoadCredentialsFile reads a file of line-separated key=value entries for "username" and "password".
+// Blank lines and lines beginning with '#' are ignored
+func LoadCredentialsFile(path string) (*Credentials, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	credFile, err := os.Open(absPath)
+	if err != nil {
+		return nil, err
+	}
+	defer credFile.Close()
+	c := &Credentials{}
+	s := bufio.NewScanner(credFile)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: %s", ErrNotInput, line)
+		}
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "username", "user":
+			c.Username = strings.TrimSpace(kv[1])
+		case "password", "pass", "pw":
+			c.Password = strings.TrimSpace(kv[1])
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrNotField, kv[0])
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	if c.Username == "" {
+		return nil, ErrNotInput
+	}
+	return c, nil
+}
+
+// Cookie generates the session cookie expected by the OLT Restconf server, which is also parsed for the Ftp login
+func (c *Credentials) Cookie() string {
+	return fmt.Sprintf("session=em+protection-user=%s&em+protection-pw=%s", url.QueryEscape(c.Username), url.QueryEscape(c.Password))
+}
@@ -0,0 +1,475 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+)
+
+// kinds of PlanStep
+const (
+	PlanCreate  = "create"
+	PlanPatch   = "patch"
+	PlanReplace = "replace"
+	PlanDelete  = "delete"
+)
+
+// profileConstructors build a profile of each table with the defaults of its New*Profile constructor
+var profileConstructors = map[string]func(name string) interface{}{
+	vlanProfiles:     func(name string) interface{} { return NewVlanProfile(name) },
+	flowProfiles:     func(name string) interface{} { return NewFlowProfile(name) },
+	securityProfiles: func(name string) interface{} { return NewSecurityProfile(name) },
+	igmpProfiles:     func(name string) interface{} { return NewIgmpProfile(name) },
+	l2cpProfiles:     func(name string) interface{} { return NewL2cpProfile(name) },
+	onuFlowProfiles:  func(name string) interface{} { return NewOnuFlowProfile(name) },
+	onuTcontProfiles: func(name string) interface{} { return NewOnuTcontProfile(name) },
+	onuVlanProfiles:  func(name string) interface{} { return NewOnuVlanProfile(name) },
+	onuIgmpProfiles:  func(name string) interface{} { return NewOnuIgmpProfile(name) },
+	serviceProfiles:  func(name string) interface{} { return NewServiceProfile(name) },
+}
+
+// DesiredState is a catalogue of profiles that an OLT should hold. Each profile is written in the same form as its
+// GenerateJson output, and any value left out of the file takes the default of the profile's constructor.
+// An OnuVlanProfile without Rules leaves the rules on the OLT unmanaged
+type DesiredState struct {
+	VlanProfiles         []*VlanProfile     `json:"vlanProfiles,omitempty"`
+	FlowProfiles         []*FlowProfile     `json:"flowProfiles,omitempty"`
+	SecurityProfiles     []*SecurityProfile `json:"securityProfiles,omitempty"`
+	MulticastProfiles    []*IgmpProfile     `json:"multicastProfiles,omitempty"`
+	L2cpProfiles         []*L2cpProfile     `json:"l2cpProfiles,omitempty"`
+	OnuFlowProfiles      []*OnuFlowProfile  `json:"onuFlowProfiles,omitempty"`
+	OnuTcontProfiles     []*OnuTcontProfile `json:"onuTcontProfiles,omitempty"`
+	OnuVlanProfiles      []*OnuVlanProfile  `json:"onuVlanProfiles,omitempty"`
+	OnuMulticastProfiles []*OnuIgmpProfile  `json:"onuMulticastProfiles,omitempty"`
+	ServiceProfiles      []*ServiceProfile  `json:"serviceProfiles,omitempty"`
+}
+
+// desiredTable pairs a list of the DesiredState with its file key and OLT table
+type desiredTable struct {
+	key   string
+	table string
+	list  reflect.Value
+}
+
+// tables returns the lists of the DesiredState in dependency order, sub-profiles before the Service Profiles that use them
+func (ds *DesiredState) tables() []desiredTable {
+	return []desiredTable{
+		{"vlanProfiles", vlanProfiles, reflect.ValueOf(&ds.VlanProfiles).Elem()},
+		{"flowProfiles", flowProfiles, reflect.ValueOf(&ds.FlowProfiles).Elem()},
+		{"securityProfiles", securityProfiles, reflect.ValueOf(&ds.SecurityProfiles).Elem()},
+		{"multicastProfiles", igmpProfiles, reflect.ValueOf(&ds.MulticastProfiles).Elem()},
+		{"l2cpProfiles", l2cpProfiles, reflect.ValueOf(&ds.L2cpProfiles).Elem()},
+		{"onuFlowProfiles", onuFlowProfiles, reflect.ValueOf(&ds.OnuFlowProfiles).Elem()},
+		{"onuTcontProfiles", onuTcontProfiles, reflect.ValueOf(&ds.OnuTcontProfiles).Elem()},
+		{"onuVlanProfiles", onuVlanProfiles, reflect.ValueOf(&ds.OnuVlanProfiles).Elem()},
+		{"onuMulticastProfiles", onuIgmpProfiles, reflect.ValueOf(&ds.OnuMulticastProfiles).Elem()},
+		{"serviceProfiles", serviceProfiles, reflect.ValueOf(&ds.ServiceProfiles).Elem()},
+	}
+}
+
+// LoadDesiredState reads a DesiredState Json file, filling every profile from its constructor before applying the file
+func LoadDesiredState(path string) (*DesiredState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string][]json.RawMessage)
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+	ds := &DesiredState{}
+	known := make(map[string]bool)
+	for _, t := range ds.tables() {
+		known[t.key] = true
+		for _, item := range raw[t.key] {
+			p, err := newProfileFromJson(t.table, item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", t.key, err)
+			}
+			t.list.Set(reflect.Append(t.list, reflect.ValueOf(p)))
+		}
+	}
+	for k := range raw {
+		if !known[k] {
+			return nil, fmt.Errorf("%w: %s", ErrNotField, k)
+		}
+	}
+	return ds, nil
+}
+
+// newProfileFromJson decodes a profile over the defaults of its constructor
+func newProfileFromJson(table string, item []byte) (interface{}, error) {
+	// the name is read first so the constructor sees it, as it would when building the profile in code
+	p := profileConstructors[table]("")
+	err := json.Unmarshal(item, p)
+	if err != nil {
+		return nil, err
+	}
+	name := reflect.ValueOf(p).Elem().FieldByName("Name").String()
+	if name == "" {
+		return nil, ErrNotInput
+	}
+	p = profileConstructors[table](name)
+	err = json.Unmarshal(item, p)
+	if err != nil {
+		return nil, err
+	}
+	if ovp, ok := p.(*OnuVlanProfile); ok && ovp.Rules != nil {
+		for _, r := range ovp.Rules.Entry {
+			if r.Name == "" {
+				r.Name = name
+			}
+		}
+	}
+	return p, nil
+}
+
+// DesiredStateFromTree builds a DesiredState holding every profile of a tree, a starting point for a catalogue file
+func DesiredStateFromTree(im *IskratelMsan) *DesiredState {
+	ds := &DesiredState{}
+	for _, t := range ds.tables() {
+		entries := im.tableEntries(t.table)
+		for i := 0; i < entries.Len(); i++ {
+			p := reflect.New(entries.Type().Elem())
+			p.Elem().Set(entries.Index(i))
+			// Usage is maintained by the OLT and is not part of the catalogue
+			p.Elem().FieldByName("Usage").SetInt(0)
+			if ovp, ok := p.Interface().(*OnuVlanProfile); ok {
+				ovp.Rules = im.onuVlanRulesOf(ovp.Name)
+			}
+			t.list.Set(reflect.Append(t.list, p))
+		}
+	}
+	return ds
+}
+
+// WriteFile serializes the DesiredState as indented Json to path
+func (ds *DesiredState) WriteFile(path string) error {
+	data, err := json.MarshalIndent(ds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// PlanStep is a single change needed to bring a profile of the OLT to the DesiredState
+type PlanStep struct {
+	Action  string         `json:"action"`
+	Table   string         `json:"table"`
+	Name    string         `json:"name"`
+	Fields  []*FieldChange `json:"fields,omitempty"`
+	profile interface{}
+	current interface{} // the profile on the OLT with its rules, posted back if a replace fails
+}
+
+// Plan is the ordered list of changes computed by PlanDesiredState
+type Plan struct {
+	Entry []*PlanStep
+}
+
+// PlanDesiredState compares the DesiredState with the profiles on l.Host and lists the steps that would reconcile them.
+// Creates and patches are ordered so every referenced profile exists first. Profiles missing from the DesiredState are
+// only deleted when prune is set: Service Profiles before the other steps, sub-profiles after them in reverse dependency order.
+// As rules cannot be posted independent of their OnuVlanProfile, a profile whose rules differ is replaced: deleted and
+// posted again with its rules. A profile in use by a ServiceProfile cannot be deleted, so its plan is refused with a DependencyError
+func (l *LumiaOlt) PlanDesiredState(ds *DesiredState, prune bool) (*Plan, error) {
+	return l.PlanDesiredStateCtx(context.Background(), ds, prune)
+}
+
+// PlanDesiredStateCtx is PlanDesiredState with its requests bounded by ctx
+func (l *LumiaOlt) PlanDesiredStateCtx(ctx context.Context, ds *DesiredState, prune bool) (*Plan, error) {
+	live, err := l.GetAllProfilesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var graph *ProfileGraph
+	plan := &Plan{}
+	var deletes [][]*PlanStep
+	for _, t := range ds.tables() {
+		present := keyEntries(live.tableEntries(t.table), tableKeys[t.table])
+		wanted := make(map[string]bool)
+		for i := 0; i < t.list.Len(); i++ {
+			p := t.list.Index(i)
+			name := p.Elem().FieldByName("Name").String()
+			if wanted[name] {
+				return nil, fmt.Errorf("%w: %s %s is listed twice", ErrExists, t.key, name)
+			}
+			wanted[name] = true
+			cur, ok := present[name]
+			if !ok {
+				plan.Entry = append(plan.Entry, &PlanStep{Action: PlanCreate, Table: t.table, Name: name, profile: p.Interface()})
+				continue
+			}
+			step := &PlanStep{Action: PlanPatch, Table: t.table, Name: name, profile: p.Interface()}
+			for _, f := range diffFields(cur, p.Elem()) {
+				if f.Field != "Usage" {
+					step.Fields = append(step.Fields, f)
+				}
+			}
+			if ovp, ok := p.Interface().(*OnuVlanProfile); ok && ovp.Rules != nil {
+				liveRules := live.onuVlanRulesOf(name)
+				if rules := ruleChanges(liveRules, ovp.Rules); len(rules) > 0 {
+					if graph == nil {
+						graph = NewProfileGraph(live)
+					}
+					deps := graph.DependentsOf(t.table, name)
+					if len(deps.Entry) > 0 {
+						return nil, &DependencyError{Node: ProfileNode{t.table, name}, Dependents: deps}
+					}
+					old := cur.Interface().(OnuVlanProfile)
+					old.Usage = 0
+					old.Rules = liveRules
+					step.Action = PlanReplace
+					step.current = &old
+					step.Fields = append(step.Fields, rules...)
+				}
+			}
+			if len(step.Fields) > 0 {
+				plan.Entry = append(plan.Entry, step)
+			}
+		}
+		if !prune {
+			continue
+		}
+		var names []string
+		for name := range present {
+			if !wanted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		var steps []*PlanStep
+		for _, name := range names {
+			steps = append(steps, &PlanStep{Action: PlanDelete, Table: t.table, Name: name})
+		}
+		deletes = append(deletes, steps)
+	}
+	if len(deletes) < 1 {
+		return plan, nil
+	}
+	// unwanted Service Profiles go first so the sub-profiles they reference are free to be replaced,
+	// the remaining deletes run last in reverse dependency order
+	last := len(deletes) - 1
+	plan.Entry = append(deletes[last], plan.Entry...)
+	for i := last - 1; i >= 0; i-- {
+		plan.Entry = append(plan.Entry, deletes[i]...)
+	}
+	return plan, nil
+}
+
+// ruleChanges compares the rules of an OnuVlanProfile by Rule ID, naming each changed field after its rule
+func ruleChanges(live, want *OnuVlanRuleList) []*FieldChange {
+	current := make(map[int]*OnuVlanRule)
+	for _, r := range live.Entry {
+		current[r.RuleID] = r
+	}
+	var list []*FieldChange
+	seen := make(map[int]bool)
+	for _, r := range want.Entry {
+		seen[r.RuleID] = true
+		c, ok := current[r.RuleID]
+		if !ok {
+			list = append(list, &FieldChange{Field: fmt.Sprintf("Rule%d", r.RuleID), New: EntryAdded})
+			continue
+		}
+		for _, f := range diffFields(reflect.ValueOf(*c), reflect.ValueOf(*r)) {
+			f.Field = fmt.Sprintf("Rule%d.%s", r.RuleID, f.Field)
+			list = append(list, f)
+		}
+	}
+	for _, r := range live.Entry {
+		if !seen[r.RuleID] {
+			list = append(list, &FieldChange{Field: fmt.Sprintf("Rule%d", r.RuleID), New: EntryRemoved})
+		}
+	}
+	return list
+}
+
+// IsEmpty reports whether the OLT already matches the DesiredState
+func (p *Plan) IsEmpty() bool {
+	return len(p.Entry) < 1
+}
+
+// Apply executes a Plan returned by PlanDesiredState through the Post, Patch and Delete methods of each profile.
+// Steps run in order and the first failure stops the Apply, as later steps may depend on it
+func (l *LumiaOlt) Apply(p *Plan) error {
+	return l.ApplyCtx(context.Background(), p)
+}
+
+// ApplyCtx is Apply with its requests bounded by ctx
+func (l *LumiaOlt) ApplyCtx(ctx context.Context, p *Plan) error {
+	for _, s := range p.Entry {
+		err := l.applyStep(ctx, s)
+		if err != nil {
+			return fmt.Errorf("%s %s %s: %w", s.Action, s.Table, s.Name, err)
+		}
+	}
+	return nil
+}
+
+func (l *LumiaOlt) applyStep(ctx context.Context, s *PlanStep) error {
+	switch s.Action {
+	case PlanCreate:
+		data, err := json.Marshal(s.profile)
+		if err != nil {
+			return err
+		}
+		return l.postProfile(ctx, s.Table, s.Name, data)
+	case PlanPatch:
+		data, err := leafBody(s.profile, s.Name, s.Fields)
+		if err != nil {
+			return err
+		}
+		return l.patchRecorded(ctx, s.Table, s.Name, data)
+	case PlanReplace:
+		return l.replaceStep(ctx, s)
+	case PlanDelete:
+		return l.deleteProfile(ctx, s.Table, s.Name)
+	}
+	return ErrNotInput
+}
+
+// replaceStep deletes a profile and posts it again with its rules. If the post fails, the profile as it was on the OLT
+// is posted back, even once ctx is done
+func (l *LumiaOlt) replaceStep(ctx context.Context, s *PlanStep) error {
+	data, err := json.Marshal(s.profile)
+	if err != nil {
+		return err
+	}
+	old, err := json.Marshal(s.current)
+	if err != nil {
+		return err
+	}
+	err = l.deleteProfile(ctx, s.Table, s.Name)
+	if err != nil {
+		return err
+	}
+	var undo []func() error
+	undo = append(undo, func() error { return l.postProfile(context.Background(), s.Table, s.Name, old) })
+	err = l.postProfile(ctx, s.Table, s.Name, data)
+	if err != nil {
+		return l.rollback(err, undo)
+	}
+	return nil
+}
+
+// leafBody builds the Patch body that sets the changed leaves of a profile to their new values, along with the key
+func leafBody(profile interface{}, name string, leaves []*FieldChange) ([]byte, error) {
+	t := reflect.TypeOf(profile).Elem()
+	nameField, _ := t.FieldByName("Name")
+	body := map[string]interface{}{jsonName(nameField): name}
+	for _, f := range leaves {
+		sf, ok := t.FieldByName(f.Field)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotField, f.Field)
+		}
+		body[jsonName(sf)] = f.New
+	}
+	return json.Marshal(body)
+}
+
+// postProfile calls the Post method of the profile table
+func (l *LumiaOlt) postProfile(ctx context.Context, table, name string, data []byte) error {
+	switch table {
+	case vlanProfiles:
+		return l.PostVlanProfileCtx(ctx, name, data)
+	case flowProfiles:
+		return l.PostFlowProfileCtx(ctx, name, data)
+	case securityProfiles:
+		return l.PostSecurityProfileCtx(ctx, name, data)
+	case igmpProfiles:
+		return l.PostMulticastProfileCtx(ctx, name, data)
+	case l2cpProfiles:
+		return l.PostL2cpProfileCtx(ctx, name, data)
+	case onuFlowProfiles:
+		return l.PostOnuFlowProfileCtx(ctx, name, data)
+	case onuTcontProfiles:
+		return l.PostOnuTcontProfileCtx(ctx, name, data)
+	case onuVlanProfiles:
+		return l.PostOnuVlanProfileCtx(ctx, name, data)
+	case onuIgmpProfiles:
+		return l.PostOnuMulticastProfileCtx(ctx, name, data)
+	case serviceProfiles:
+		return l.PostServiceProfileCtx(ctx, name, data)
+	}
+	return fmt.Errorf("%w: %s", ErrNotField, table)
+}
+
+// deleteProfile calls the Delete method of the profile table
+func (l *LumiaOlt) deleteProfile(ctx context.Context, table, name string) error {
+	switch table {
+	case vlanProfiles:
+		return l.DeleteVlanProfileCtx(ctx, name)
+	case flowProfiles:
+		return l.DeleteFlowProfileCtx(ctx, name)
+	case securityProfiles:
+		return l.DeleteSecurityProfileCtx(ctx, name)
+	case igmpProfiles:
+		return l.DeleteMulticastProfileCtx(ctx, name)
+	case l2cpProfiles:
+		return l.DeleteL2cpProfileCtx(ctx, name)
+	case onuFlowProfiles:
+		return l.DeleteOnuFlowProfileCtx(ctx, name)
+	case onuTcontProfiles:
+		return l.DeleteOnuTcontProfileCtx(ctx, name)
+	case onuVlanProfiles:
+		return l.DeleteOnuVlanProfileCtx(ctx, name)
+	case onuIgmpProfiles:
+		return l.DeleteOnuMulticastProfileCtx(ctx, name)
+	case serviceProfiles:
+		return l.DeleteServiceProfileCtx(ctx, name)
+	}
+	return fmt.Errorf("%w: %s", ErrNotField, table)
+}
+
+// GenerateJson serializes the Plan as an indented list for review
+func (p *Plan) GenerateJson() ([]byte, error) {
+	list := p.Entry
+	if list == nil {
+		list = []*PlanStep{}
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
+
+var PlanHeaders = []string{
+	"Action",
+	"Table",
+	"Name",
+	"Field",
+	"Old",
+	"New",
+}
+
+// Tabwrite displays the Plan in organized columns, one row per changed field
+func (p *Plan) Tabwrite() {
+	fmt.Println("|| Plan ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range PlanHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range PlanHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, s := range p.Entry {
+		if len(s.Fields) < 1 {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t\t\t\t\n", s.Action, s.Table, s.Name)
+			continue
+		}
+		for _, f := range s.Fields {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t\n", s.Action, s.Table, s.Name, f.Field, f.Old, f.New)
+		}
+	}
+	for _, v := range PlanHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
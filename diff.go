@@ -0,0 +1,229 @@
+package goPon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// kinds of EntryChange
+const (
+	EntryAdded    = "added"
+	EntryRemoved  = "removed"
+	EntryModified = "modified"
+)
+
+// tableKeys lists the fields that identify an entry in each table, joined with ',' as in the Restconf Url
+var tableKeys = map[string][]string{
+	serviceProfiles:  {"Name"},
+	flowProfiles:     {"Name"},
+	vlanProfiles:     {"Name"},
+	igmpProfiles:     {"Name"},
+	securityProfiles: {"Name"},
+	onuFlowProfiles:  {"Name"},
+	onuTcontProfiles: {"Name"},
+	onuVlanProfiles:  {"Name"},
+	onuVlanRules:     {"Name", "RuleID"},
+	onuIgmpProfiles:  {"Name"},
+	l2cpProfiles:     {"Name"},
+	onuBlacklist:     {"IfName", "SerialNumber"},
+	onuConfig:        {"IfName"},
+	onuInfo:          {"IfName"},
+	onuProfiles:      {"IfName", "ServiceProfileName"},
+}
+
+// FieldChange is a single value that differs between two versions of an entry
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// EntryChange describes an entry that was added, removed or modified in a table
+type EntryChange struct {
+	Table  string         `json:"table"`
+	Key    string         `json:"key"`
+	Change string         `json:"change"`
+	Fields []*FieldChange `json:"fields,omitempty"`
+}
+
+// MsanDiff is the set of changes between two IskratelMsan trees
+type MsanDiff struct {
+	Entry []*EntryChange
+}
+
+// DiffIskratelMsan compares two trees table by table, matching entries by their key.
+// A nil tree is treated as empty, so diffing against nil lists every entry as added or removed
+func DiffIskratelMsan(from, to *IskratelMsan) *MsanDiff {
+	if from == nil {
+		from = NewIskratelMsan()
+	}
+	if to == nil {
+		to = NewIskratelMsan()
+	}
+	d := &MsanDiff{}
+	ov := reflect.ValueOf(from.ISKRATELMSANMIB.ISKRATELMSANMIB)
+	nv := reflect.ValueOf(to.ISKRATELMSANMIB.ISKRATELMSANMIB)
+	for i := 0; i < ov.NumField(); i++ {
+		table := jsonName(ov.Type().Field(i))
+		keys, ok := tableKeys[table]
+		if !ok {
+			continue
+		}
+		// each table struct holds a single slice of entries
+		oldEntries := keyEntries(ov.Field(i).Field(0), keys)
+		newEntries := keyEntries(nv.Field(i).Field(0), keys)
+		d.Entry = append(d.Entry, diffTable(table, oldEntries, newEntries)...)
+	}
+	return d
+}
+
+// CacheDiff returns the changes from Cache to Current
+func (l *LumiaOlt) CacheDiff() *MsanDiff {
+	l.treeMu.Lock()
+	from, to := l.Cache, l.Current
+	l.treeMu.Unlock()
+	return DiffIskratelMsan(from, to)
+}
+
+// diffTable compares the keyed entries of one table, reporting changes ordered by key
+func diffTable(table string, oldEntries, newEntries map[string]reflect.Value) []*EntryChange {
+	var keys []string
+	for k := range oldEntries {
+		keys = append(keys, k)
+	}
+	for k := range newEntries {
+		if _, ok := oldEntries[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var list []*EntryChange
+	for _, k := range keys {
+		o, inOld := oldEntries[k]
+		n, inNew := newEntries[k]
+		switch {
+		case !inOld:
+			list = append(list, &EntryChange{Table: table, Key: k, Change: EntryAdded})
+		case !inNew:
+			list = append(list, &EntryChange{Table: table, Key: k, Change: EntryRemoved})
+		default:
+			if fields := diffFields(o, n); len(fields) > 0 {
+				list = append(list, &EntryChange{Table: table, Key: k, Change: EntryModified, Fields: fields})
+			}
+		}
+	}
+	return list
+}
+
+// diffFields compares the serialized fields of two entries of the same type
+func diffFields(o, n reflect.Value) []*FieldChange {
+	var list []*FieldChange
+	for i := 0; i < o.NumField(); i++ {
+		f := o.Type().Field(i)
+		// untagged fields such as OnuVlanProfile.Rules are not part of the table
+		if jsonName(f) == "" {
+			continue
+		}
+		ov := o.Field(i).Interface()
+		nv := n.Field(i).Interface()
+		if !reflect.DeepEqual(ov, nv) {
+			list = append(list, &FieldChange{Field: f.Name, Old: ov, New: nv})
+		}
+	}
+	return list
+}
+
+// keyEntries indexes a slice of entries by the values of the key fields
+func keyEntries(slice reflect.Value, keys []string) map[string]reflect.Value {
+	m := make(map[string]reflect.Value)
+	for i := 0; i < slice.Len(); i++ {
+		e := slice.Index(i)
+		m[entryKey(e, keys)] = e
+	}
+	return m
+}
+
+// entryKey joins the values of the key fields of an entry with ','
+func entryKey(e reflect.Value, keys []string) string {
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprint(e.FieldByName(k).Interface()))
+	}
+	return strings.Join(parts, ",")
+}
+
+// jsonName returns the json name of a struct field, or "" if it has none
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "" || tag == "-" {
+		return ""
+	}
+	return strings.Split(tag, ",")[0]
+}
+
+// IsEmpty reports whether the trees compared were identical
+func (d *MsanDiff) IsEmpty() bool {
+	return len(d.Entry) < 1
+}
+
+// Filter returns the changes made to a single table
+func (d *MsanDiff) Filter(table string) *MsanDiff {
+	f := &MsanDiff{}
+	for _, e := range d.Entry {
+		if e.Table == table {
+			f.Entry = append(f.Entry, e)
+		}
+	}
+	return f
+}
+
+// GenerateJson serializes the change set as an indented list
+func (d *MsanDiff) GenerateJson() ([]byte, error) {
+	list := d.Entry
+	if list == nil {
+		list = []*EntryChange{}
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
+
+var MsanDiffHeaders = []string{
+	"Table",
+	"Key",
+	"Change",
+	"Field",
+	"Old",
+	"New",
+}
+
+// Tabwrite displays the change set in organized columns, one row per changed field
+func (d *MsanDiff) Tabwrite() {
+	fmt.Println("|| Change Set ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range MsanDiffHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range MsanDiffHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, e := range d.Entry {
+		if len(e.Fields) < 1 {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t\t\t\t\n", e.Table, e.Key, e.Change)
+			continue
+		}
+		for _, f := range e.Fields {
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t\n", e.Table, e.Key, e.Change, f.Field, f.Old, f.New)
+		}
+	}
+	for _, v := range MsanDiffHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
@@ -0,0 +1,307 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// DefaultFleetConcurrency is the number of Olt a Fleet queries at once unless changed
+const DefaultFleetConcurrency = 8
+
+// FleetOlt is one Olt of a Fleet inventory. The login is read from CredentialsFile when set,
+// otherwise from Username and Password, otherwise from the environment as by NewLumiaOlt
+type FleetOlt struct {
+	Name            string    `json:"name"`
+	Host            string    `json:"host"`
+	Username        string    `json:"username,omitempty"`
+	Password        string    `json:"password,omitempty"`
+	CredentialsFile string    `json:"credentialsFile,omitempty"`
+	Site            string    `json:"site,omitempty"`
+	Tags            []string  `json:"tags,omitempty"`
+	Olt             *LumiaOlt `json:"-"`
+}
+
+// HasTag reports whether the Olt is tagged with t
+func (fo *FleetOlt) HasTag(t string) bool {
+	for _, v := range fo.Tags {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Fleet runs reads across many Olt at once, merging the results tagged by the Olt they came from
+type Fleet struct {
+	Entry       []*FleetOlt
+	Concurrency int          // Olt queried at once, DefaultFleetConcurrency unless changed
+	Progress    BulkProgress // called as each Olt of a query completes, if set
+}
+
+// fleetInventory is the file format read by LoadFleet
+type fleetInventory struct {
+	Olts []*FleetOlt `json:"olts"`
+}
+
+// LoadFleet reads a Json inventory of the form {"olts": [{"name": ..., "host": ..., "site": ..., "tags": [...]}, ...]}
+// and returns a Fleet of its Olt
+func LoadFleet(path string) (*Fleet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var inv fleetInventory
+	err = json.Unmarshal(data, &inv)
+	if err != nil {
+		return nil, err
+	}
+	return NewFleet(inv.Olts)
+}
+
+// NewFleet sets up a LumiaOlt for each entry of the inventory. Every entry needs a Host and a Name no other entry has
+func NewFleet(olts []*FleetOlt) (*Fleet, error) {
+	names := make(map[string]bool)
+	for _, fo := range olts {
+		if fo.Name == "" || fo.Host == "" {
+			return nil, fmt.Errorf("%w: Olt needs a name and host: %q %q", ErrNotInput, fo.Name, fo.Host)
+		}
+		if names[fo.Name] {
+			return nil, fmt.Errorf("%w: %s", ErrExists, fo.Name)
+		}
+		names[fo.Name] = true
+		switch {
+		case fo.CredentialsFile != "":
+			cred, err := LoadCredentialsFile(fo.CredentialsFile)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fo.Name, err)
+			}
+			fo.Olt = NewLumiaOltWithCredentials(fo.Host, cred)
+		case fo.Username != "" || fo.Password != "":
+			fo.Olt = NewLumiaOltWithCredentials(fo.Host, NewCredentials(fo.Username, fo.Password))
+		default:
+			fo.Olt = NewLumiaOlt(fo.Host)
+		}
+	}
+	return &Fleet{Entry: olts, Concurrency: DefaultFleetConcurrency}, nil
+}
+
+// FleetFilter selects Olt of a Fleet; every field that is set must match
+type FleetFilter struct {
+	Names []string // any of these names
+	Site  string
+	Tags  []string // every one of these tags
+}
+
+// Match reports whether the FleetOlt passes the filter
+func (ff FleetFilter) Match(fo *FleetOlt) bool {
+	if len(ff.Names) > 0 {
+		found := false
+		for _, n := range ff.Names {
+			if n == fo.Name {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if ff.Site != "" && ff.Site != fo.Site {
+		return false
+	}
+	for _, t := range ff.Tags {
+		if !fo.HasTag(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the Olt of the Fleet matching the filter, in inventory order
+func (f *Fleet) Select(ff FleetFilter) []*FleetOlt {
+	var list []*FleetOlt
+	for _, fo := range f.Entry {
+		if ff.Match(fo) {
+			list = append(list, fo)
+		}
+	}
+	return list
+}
+
+// FleetResult is the value a Query read from one Olt
+type FleetResult struct {
+	Olt   *FleetOlt
+	Value interface{}
+}
+
+// Query calls fn for each Olt matching the filter, f.Concurrency at a time, and returns the values read in inventory order.
+// An Olt that fails does not stop the others: the values of the rest are returned along with a *BulkError naming it.
+// Once ctx is done the Olt not yet queried fail with its error; fn is handed ctx to bound its requests
+func (f *Fleet) Query(ctx context.Context, ff FleetFilter, fn func(ctx context.Context, l *LumiaOlt) (interface{}, error)) ([]*FleetResult, error) {
+	olts := f.Select(ff)
+	if len(olts) == 0 {
+		return nil, fmt.Errorf("%w: no Olt matches the filter", ErrNotExists)
+	}
+	names := make([]string, len(olts))
+	for i, fo := range olts {
+		names[i] = fo.Name
+	}
+	values := make([]*FleetResult, len(olts))
+	err := runConcurrent(ctx, f.Concurrency, f.Progress, names, func(i int, _ string) error {
+		v, err := fn(ctx, olts[i].Olt)
+		if err != nil {
+			return err
+		}
+		values[i] = &FleetResult{Olt: olts[i], Value: v}
+		return nil
+	})
+	var results []*FleetResult
+	for _, r := range values {
+		if r != nil {
+			results = append(results, r)
+		}
+	}
+	return results, err
+}
+
+// FleetBlacklistEntry is an OnuBlacklist entry of an Olt of the Fleet
+type FleetBlacklistEntry struct {
+	Olt  string
+	Site string
+	*OnuBlacklist
+}
+
+type FleetBlacklist struct {
+	Entry []*FleetBlacklistEntry
+}
+
+// GetOnuBlacklist merges the Blacklist of every Olt matching the filter
+func (f *Fleet) GetOnuBlacklist(ctx context.Context, ff FleetFilter) (*FleetBlacklist, error) {
+	results, err := f.Query(ctx, ff, func(ctx context.Context, l *LumiaOlt) (interface{}, error) {
+		return l.GetOnuBlacklistCtx(ctx)
+	})
+	list := &FleetBlacklist{}
+	for _, r := range results {
+		for _, e := range r.Value.(*OnuBlacklistList).Entry {
+			list.Entry = append(list.Entry, &FleetBlacklistEntry{Olt: r.Olt.Name, Site: r.Olt.Site, OnuBlacklist: e})
+		}
+	}
+	return list, err
+}
+
+// FleetProfileUse is the use of a Service Profile on an Olt of the Fleet
+type FleetProfileUse struct {
+	Olt        string
+	Site       string
+	Profile    string
+	Defined    bool     // the Service Profile exists on the Olt
+	Interfaces []string // Onu interfaces it is bound to
+}
+
+type FleetProfileUseList struct {
+	Entry []*FleetProfileUse
+}
+
+// GetServiceProfileUsage lists every Olt matching the filter that defines the Service Profile or has Onu bound to it
+func (f *Fleet) GetServiceProfileUsage(ctx context.Context, ff FleetFilter, sp string) (*FleetProfileUseList, error) {
+	if sp == "" {
+		return nil, ErrNotInput
+	}
+	results, err := f.Query(ctx, ff, func(ctx context.Context, l *LumiaOlt) (interface{}, error) {
+		use := &FleetProfileUse{Profile: sp}
+		spl, err := l.GetServiceProfilesCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range spl.Entry {
+			if p.Name == sp {
+				use.Defined = true
+			}
+		}
+		opl, err := l.GetOnuProfileUsageCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range opl.Entry {
+			if op.ServiceProfileName == sp {
+				use.Interfaces = append(use.Interfaces, op.IfName)
+			}
+		}
+		return use, nil
+	})
+	list := &FleetProfileUseList{}
+	for _, r := range results {
+		use := r.Value.(*FleetProfileUse)
+		if !use.Defined && len(use.Interfaces) == 0 {
+			continue
+		}
+		use.Olt, use.Site = r.Olt.Name, r.Olt.Site
+		list.Entry = append(list.Entry, use)
+	}
+	return list, err
+}
+
+// tabwriteFleet displays the rows under the headers in organized columns
+func tabwriteFleet(title string, headers []string, rows [][]interface{}) {
+	fmt.Printf("|| %s ||\n", title)
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range headers {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range headers {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, row := range rows {
+		for _, v := range row {
+			fmt.Fprintf(tw, "%v\t", v)
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	for _, v := range headers {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
+
+var FleetBlacklistHeaders = []string{
+	"OLT",
+	"Site",
+	"Interface",
+	"Serial Number",
+	"Password",
+	"Cause",
+}
+
+// Tabwrite displays the merged Blacklist in organized columns
+func (fbl *FleetBlacklist) Tabwrite() {
+	var rows [][]interface{}
+	for _, e := range fbl.Entry {
+		rows = append(rows, []interface{}{e.Olt, e.Site, e.IfName, e.SerialNumber, e.Password, e.GetBlCause()})
+	}
+	tabwriteFleet("Fleet Blacklist", FleetBlacklistHeaders, rows)
+}
+
+var FleetProfileUseHeaders = []string{
+	"OLT",
+	"Site",
+	"Service Profile",
+	"Defined",
+	"Onu",
+	"Interfaces",
+}
+
+// Tabwrite displays the Olt using the Service Profile in organized columns
+func (fpl *FleetProfileUseList) Tabwrite() {
+	var rows [][]interface{}
+	for _, u := range fpl.Entry {
+		rows = append(rows, []interface{}{u.Olt, u.Site, u.Profile, u.Defined, len(u.Interfaces), strings.Join(u.Interfaces, ", ")})
+	}
+	tabwriteFleet("Fleet Service Profile Usage", FleetProfileUseHeaders, rows)
+}
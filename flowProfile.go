@@ -185,10 +185,10 @@ func (p *FlowProfile) Copy(newName string) (*FlowProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 // GetMatchBothVlanProfile returns a bool of if the FlowProfile logic is set to match what is set in the Vlan Profile in the same Service Profile, the most common scenario
@@ -4,26 +4,28 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 )
 
 var (
-	ErrNotStruct     = errors.New("Not a valid struct")
-	ErrNotField      = errors.New("Not a valid field name")
-	ErrNotExported   = errors.New("Not an exported field")
-	ErrNotSettable   = errors.New("Not a settable field")
-	ErrNotInput      = errors.New("Incorrect input supplied")
-	ErrNotExists     = errors.New("Key not found")
-	ErrExists        = errors.New("Key already exists")
-	ErrInUse         = errors.New("Cannot modify while in use")
-	ErrNotStatusOk   = errors.New("Did not receive 200 OK from HTTP server")
-	ErrNotReachable  = errors.New("Host not reachable")
-	ErrNotAuthorized = errors.New("Onu Sn not on Authorized List")
+	ErrNotStruct        = errors.New("Not a valid struct")
+	ErrNotField         = errors.New("Not a valid field name")
+	ErrNotExported      = errors.New("Not an exported field")
+	ErrNotSettable      = errors.New("Not a settable field")
+	ErrNotInput         = errors.New("Incorrect input supplied")
+	ErrNotExists        = errors.New("Key not found")
+	ErrExists           = errors.New("Key already exists")
+	ErrInUse            = errors.New("Cannot modify while in use")
+	ErrNotStatusOk      = errors.New("Did not receive 200 OK from HTTP server")
+	ErrNotReachable     = errors.New("Host not reachable")
+	ErrNotAuthorized    = errors.New("Onu Sn not on Authorized List")
+	ErrNotVersion       = errors.New("Unsupported snapshot version")
+	ErrPasswordMismatch = errors.New("Onu password does not match the Authorized List")
 )
 
 const (
-	auth             = "session=em+protection-user=admin&em+protection-pw=admin"
 	empty            = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
 )
 
@@ -157,17 +159,20 @@ func UrlDecodeInterface(intf string) string {
 	return strings.ReplaceAll(intf, "%2F", "/")
 }
 
+// parseAuth extracts the username and password from a session cookie generated by Credentials.Cookie
 func parseAuth(auth string) (user, pass string) {
 	str := strings.Split(auth, "&")
 	for _, st := range str {
 		s := strings.Split(st, "=")
-		if strings.Contains(s[1], "user") {
-			user = s[2]
+		if len(s) < 2 {
+			continue
+		}
+		if len(s) > 2 && strings.Contains(s[1], "user") {
+			user, _ = url.QueryUnescape(s[2])
 		}
 		if strings.Contains(s[0], "pw") {
-			pass = s[1]
+			pass, _ = url.QueryUnescape(s[1])
 		}
 	}
 	return
-
 }
@@ -0,0 +1,86 @@
+package gopontest
+
+import (
+	"strconv"
+
+	"github.com/lindsaybb/goPon"
+)
+
+// SeedDemo loads the profiles of ref/testDemo.scr along with 102_DATA_Uni used by ref/authList.txt,
+// giving the simulator the Service Profiles the cmd examples expect
+func (s *Simulator) SeedDemo() error {
+	var objects []interface{}
+	for _, vid := range []int{101, 102} {
+		vp := goPon.NewVlanProfile(strconv.Itoa(vid))
+		err := vp.SetCVid([]int{vid})
+		if err != nil {
+			return err
+		}
+		ofp := goPon.NewOnuFlowProfile(strconv.Itoa(vid))
+		err = ofp.SetMatchUsCVlanIDRange([]int{vid})
+		if err != nil {
+			return err
+		}
+		objects = append(objects, vp, ofp, demoOnuVlanProfile("A"+strconv.Itoa(vid), vid))
+	}
+	fp := goPon.NewFlowProfile("MB")
+	fp.SetMatchBothVlanProfile()
+	maxTcont := goPon.NewOnuTcontProfile("T5I1__M-MAX")
+	maxTcont.SetMaxRate(1244160)
+	amTcont := goPon.NewOnuTcontProfile("T5I6_AM-20")
+	amTcont.SetTcontID(6)
+	amTcont.SetFAM(0, 5056, 20032)
+	objects = append(objects, fp, maxTcont, amTcont)
+
+	cwmp := demoServiceProfile("101_CWMP", "101", "T5I6_AM-20", "A101", 2)
+	cwmp.SetOnuTpType(2)
+	data := demoServiceProfile("102_DATA", "102", "T5I1__M-MAX", "", 10)
+	dataAcc := demoServiceProfile("102_DATA_ACC", "102", "T5I1__M-MAX", "A102", 10)
+	dataAcc.SetOnuTpType(3)
+	dataAcc.SetOnuTpUniBitMap(1)
+	dataUni := demoServiceProfile("102_DATA_Uni", "102", "T5I1__M-MAX", "A102", 10)
+	dataUni.SetOnuTpType(3)
+	dataUni.SetOnuTpUniBitMap(1)
+	objects = append(objects, cwmp, data, dataAcc, dataUni)
+
+	return s.Seed(objects...)
+}
+
+func demoServiceProfile(name, vid, tcont, onuVlan string, gem int) *goPon.ServiceProfile {
+	sp := goPon.NewServiceProfile(name)
+	sp.SetFlowProfile("MB")
+	sp.SetVlanProfile(vid)
+	sp.SetOnuTcontProfile(tcont)
+	sp.SetOnuFlowProfile(vid)
+	sp.SetOnuVlanProfile(onuVlan)
+	sp.SetVirtualGemPort(gem)
+	return sp
+}
+
+// demoOnuVlanProfile adds a C-Tag to untagged traffic with rule 10, keeping default rules 98 and 99
+func demoOnuVlanProfile(name string, vid int) *goPon.OnuVlanProfile {
+	p := goPon.NewOnuVlanProfile(name)
+	p.Rules = &goPon.OnuVlanRuleList{}
+	defaults := []int{1, 2, 0, 0, 1, 2, 0, 0, 1}
+	for _, r := range []struct {
+		id    int
+		match []int
+	}{
+		{10, []int{4096, -1, 0, 4096, -1, 0, 0}},
+		{98, []int{4096, -1, 0, -1, -1, 0, 0}},
+		{99, []int{-1, -1, 0, -1, -1, 0, 0}},
+	} {
+		rule := &goPon.OnuVlanRule{Name: name, RuleID: r.id}
+		rule.SetMatchCriteria(r.match)
+		// keep the received tags, rule 10 additionally adds the C-Tag
+		actions := append([]int(nil), defaults...)
+		actions[0] = 0
+		if r.id == 10 {
+			actions[5] = 1
+			actions[7] = vid
+		}
+		rule.SetActions(actions)
+		p.Rules.Entry = append(p.Rules.Entry, rule)
+	}
+	return p
+}
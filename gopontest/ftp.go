@@ -0,0 +1,365 @@
+package gopontest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindsaybb/goPon"
+)
+
+// DemoLogs are the log files seeded in /log by NewFtpServer, all of which GetOltLogs is expected to retrieve
+var DemoLogs = map[string]string{
+	"messages":   "Jan  1 00:00:01 olt syslogd: started\nJan  1 00:00:02 olt gpon: port 0/1 up\n",
+	"auth.log":   "Jan  1 00:00:05 olt sshd: accepted password for admin\n",
+	"kern.log":   "Jan  1 00:00:00 olt kernel: booting\n",
+	"daemon.log": "Jan  1 00:00:03 olt restconf: listening on 443\n",
+}
+
+// FtpServer is a minimal passive-mode Ftp server laid out like the OLT filesystem.
+// It supports the commands used by goftp for listing, retrieving, storing and deleting files
+type FtpServer struct {
+	Root        string             // local directory served as "/"
+	Credentials *goPon.Credentials // login accepted by USER/PASS
+	listener    net.Listener
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	conns       map[net.Conn]bool
+}
+
+// NewFtpServer creates a temporary root containing OltDirs, the DemoLogs and an entry for every
+// name in LocalOnlyLogs, and starts serving it on the loopback interface
+func NewFtpServer() (*FtpServer, error) {
+	root, err := ioutil.TempDir("", "gopontest-ftp")
+	if err != nil {
+		return nil, err
+	}
+	f := &FtpServer{
+		Root:        root,
+		Credentials: goPon.DefaultCredentials(),
+		conns:       make(map[net.Conn]bool),
+	}
+	err = f.seed()
+	if err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+	f.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		os.RemoveAll(root)
+		return nil, err
+	}
+	f.wg.Add(1)
+	go f.serve()
+	return f, nil
+}
+
+// seed builds the OLT directory layout under Root
+func (f *FtpServer) seed() error {
+	for _, d := range goPon.OltDirs {
+		err := os.MkdirAll(f.Path(d), 0755)
+		if err != nil {
+			return err
+		}
+	}
+	for name, content := range DemoLogs {
+		err := f.AddLog(name, []byte(content))
+		if err != nil {
+			return err
+		}
+	}
+	// local-only entries are a mix of directories and files on the OLT, both must be skipped
+	for _, name := range goPon.LocalOnlyLogs {
+		var err error
+		if strings.Contains(name, ".") {
+			err = f.AddLog(name, []byte("local only\n"))
+		} else {
+			err = os.MkdirAll(f.Path("/log/"+name), 0755)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Host returns the address of the server in the form accepted by NewFtpClient
+func (f *FtpServer) Host() string {
+	return f.listener.Addr().String()
+}
+
+// Path returns the local path of a file on the server
+func (f *FtpServer) Path(remote string) string {
+	return filepath.Join(f.Root, filepath.FromSlash(path.Clean("/"+remote)))
+}
+
+// AddLog writes a file into /log
+func (f *FtpServer) AddLog(name string, data []byte) error {
+	return ioutil.WriteFile(f.Path("/log/"+name), data, 0644)
+}
+
+// Close stops the server, dropping any open sessions, and removes its root directory
+func (f *FtpServer) Close() error {
+	err := f.listener.Close()
+	f.mu.Lock()
+	for c := range f.conns {
+		c.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	os.RemoveAll(f.Root)
+	return err
+}
+
+func (f *FtpServer) serve() {
+	defer f.wg.Done()
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns[conn] = true
+		f.mu.Unlock()
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			s := &ftpSession{server: f, conn: conn, r: bufio.NewReader(conn)}
+			s.run()
+			f.mu.Lock()
+			delete(f.conns, conn)
+			f.mu.Unlock()
+		}()
+	}
+}
+
+// ftpSession holds the state of one control connection
+type ftpSession struct {
+	server   *FtpServer
+	conn     net.Conn
+	r        *bufio.Reader
+	user     string
+	loggedIn bool
+	pasv     net.Listener
+}
+
+func (s *ftpSession) reply(code int, format string, a ...interface{}) {
+	fmt.Fprintf(s.conn, "%d %s\r\n", code, fmt.Sprintf(format, a...))
+}
+
+func (s *ftpSession) run() {
+	defer s.conn.Close()
+	defer s.closePasv()
+	s.reply(220, "goPon test Ftp ready")
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(time.Minute))
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			cmd, arg = line[:i], line[i+1:]
+		}
+		cmd = strings.ToUpper(cmd)
+		if !s.loggedIn && cmd != "USER" && cmd != "PASS" && cmd != "QUIT" && cmd != "FEAT" {
+			s.reply(530, "Not logged in")
+			continue
+		}
+		switch cmd {
+		case "USER":
+			s.user = arg
+			s.reply(331, "Password required")
+		case "PASS":
+			if s.user == s.server.Credentials.Username && arg == s.server.Credentials.Password {
+				s.loggedIn = true
+				s.reply(230, "Logged in")
+			} else {
+				s.reply(530, "Login incorrect")
+			}
+		case "FEAT":
+			fmt.Fprintf(s.conn, "211-Features:\r\n EPSV\r\n MLST type*;size*;modify*;\r\n SIZE\r\n UTF8\r\n211 End\r\n")
+		case "SYST":
+			s.reply(215, "UNIX Type: L8")
+		case "PWD":
+			s.reply(257, "\"/\" is the current directory")
+		case "CWD":
+			if fi, err := os.Stat(s.server.Path(arg)); err != nil || !fi.IsDir() {
+				s.reply(550, "No such directory")
+			} else {
+				s.reply(250, "Directory changed")
+			}
+		case "TYPE", "MODE", "STRU", "OPTS":
+			s.reply(200, "Ok")
+		case "EPSV", "PASV":
+			s.passive(cmd)
+		case "SIZE":
+			if fi, err := os.Stat(s.server.Path(arg)); err != nil || fi.IsDir() {
+				s.reply(550, "No such file")
+			} else {
+				s.reply(213, "%d", fi.Size())
+			}
+		case "MLST":
+			fi, err := os.Stat(s.server.Path(arg))
+			if err != nil {
+				s.reply(550, "No such file or directory")
+				continue
+			}
+			fmt.Fprintf(s.conn, "250-Listing %s\r\n %s\r\n250 End\r\n", arg, mlstFacts(fi, path.Clean("/"+arg)))
+		case "MLSD":
+			s.list(arg)
+		case "RETR":
+			s.retrieve(arg)
+		case "STOR":
+			s.store(arg)
+		case "DELE":
+			if fi, err := os.Stat(s.server.Path(arg)); err != nil || fi.IsDir() {
+				s.reply(550, "No such file")
+			} else if err = os.Remove(s.server.Path(arg)); err != nil {
+				s.reply(550, "%v", err)
+			} else {
+				s.reply(250, "File deleted")
+			}
+		case "NOOP":
+			s.reply(200, "Ok")
+		case "QUIT":
+			s.reply(221, "Goodbye")
+			return
+		default:
+			s.reply(502, "Command not implemented")
+		}
+	}
+}
+
+// passive opens a listener for the next data connection
+func (s *ftpSession) passive(cmd string) {
+	s.closePasv()
+	var err error
+	s.pasv, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		s.reply(425, "Can't open data connection")
+		return
+	}
+	port := s.pasv.Addr().(*net.TCPAddr).Port
+	if cmd == "EPSV" {
+		s.reply(229, "Entering Extended Passive Mode (|||%d|)", port)
+	} else {
+		s.reply(227, "Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff)
+	}
+}
+
+func (s *ftpSession) closePasv() {
+	if s.pasv != nil {
+		s.pasv.Close()
+		s.pasv = nil
+	}
+}
+
+// dataConn accepts the data connection prepared by EPSV or PASV
+func (s *ftpSession) dataConn() (net.Conn, error) {
+	if s.pasv == nil {
+		return nil, goPon.ErrNotReachable
+	}
+	defer s.closePasv()
+	s.pasv.(*net.TCPListener).SetDeadline(time.Now().Add(10 * time.Second))
+	return s.pasv.Accept()
+}
+
+// transfer runs fn over the data connection, sending the preliminary and completion replies
+func (s *ftpSession) transfer(fn func(dc net.Conn) error) {
+	s.reply(150, "Opening data connection")
+	dc, err := s.dataConn()
+	if err != nil {
+		s.reply(425, "Can't open data connection")
+		return
+	}
+	err = fn(dc)
+	dc.Close()
+	if err != nil {
+		s.reply(451, "%v", err)
+		return
+	}
+	s.reply(226, "Transfer complete")
+}
+
+func (s *ftpSession) list(arg string) {
+	dir := s.server.Path(arg)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		s.closePasv()
+		s.reply(550, "No such directory")
+		return
+	}
+	s.transfer(func(dc net.Conn) error {
+		for _, fi := range files {
+			_, err := fmt.Fprintf(dc, "%s\r\n", mlstFacts(fi, fi.Name()))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *ftpSession) retrieve(arg string) {
+	file, err := os.Open(s.server.Path(arg))
+	if err == nil {
+		var fi os.FileInfo
+		if fi, err = file.Stat(); err == nil && fi.IsDir() {
+			err = os.ErrNotExist
+		}
+	}
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		s.closePasv()
+		s.reply(550, "No such file")
+		return
+	}
+	defer file.Close()
+	s.transfer(func(dc net.Conn) error {
+		_, err := io.Copy(dc, file)
+		return err
+	})
+}
+
+func (s *ftpSession) store(arg string) {
+	p := s.server.Path(arg)
+	if fi, err := os.Stat(filepath.Dir(p)); err != nil || !fi.IsDir() {
+		s.closePasv()
+		s.reply(553, "No such directory")
+		return
+	}
+	file, err := os.Create(p)
+	if err != nil {
+		s.closePasv()
+		s.reply(553, "%v", err)
+		return
+	}
+	defer file.Close()
+	s.transfer(func(dc net.Conn) error {
+		_, err := io.Copy(file, dc)
+		return err
+	})
+}
+
+// mlstFacts formats a file as an MLSD/MLST entry
+func mlstFacts(fi os.FileInfo, name string) string {
+	typ := "file"
+	if fi.IsDir() {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%s;modify=%s;unix.mode=%s; %s",
+		typ, strconv.FormatInt(fi.Size(), 10), fi.ModTime().UTC().Format("20060102150405"), strconv.FormatUint(uint64(fi.Mode().Perm()), 8), name)
+}
@@ -0,0 +1,152 @@
+package gopontest
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lindsaybb/goPon"
+)
+
+// newFtpOlt starts a simulator with its Ftp server and returns a LumiaOlt using both
+func newFtpOlt(t *testing.T) (*Simulator, *goPon.LumiaOlt) {
+	t.Helper()
+	sim := NewSimulator()
+	t.Cleanup(sim.Close)
+	_, err := sim.StartFtp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	olt := sim.Olt()
+	olt.Client.Verbose = false
+	return sim, olt
+}
+
+// writeTemp writes a local file to upload and returns its path
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	err := ioutil.WriteFile(p, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestGetCurrentLogs(t *testing.T) {
+	_, olt := newFtpOlt(t)
+	out := t.TempDir()
+	err := olt.GetCurrentLogs(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the logs land in <out>/<datestamp>/log
+	dirs, err := filepath.Glob(filepath.Join(out, "*", "log"))
+	if err != nil || len(dirs) != 1 {
+		t.Fatalf("log directory: %v %v", dirs, err)
+	}
+	for name, content := range DemoLogs {
+		got, err := ioutil.ReadFile(filepath.Join(dirs[0], name))
+		if err != nil {
+			t.Errorf("%s not retrieved: %v", name, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+	files, err := ioutil.ReadDir(dirs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(DemoLogs) {
+		t.Errorf("retrieved %d files, want the %d DemoLogs", len(files), len(DemoLogs))
+	}
+	for _, name := range goPon.LocalOnlyLogs {
+		if _, err := os.Stat(filepath.Join(dirs[0], name)); err == nil {
+			t.Errorf("local-only %s was retrieved", name)
+		}
+	}
+}
+
+func TestUploadConfig(t *testing.T) {
+	sim, olt := newFtpOlt(t)
+	cases := []struct {
+		name   string
+		remote string
+	}{
+		{"upload.scr", "/config/upload.scr"},
+		{"upload.conf", "/config/onu-default/upload.conf"},
+	}
+	for _, tc := range cases {
+		content := "content of " + tc.name + "\n"
+		err := olt.UploadConfig(writeTemp(t, tc.name, content))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		got, err := ioutil.ReadFile(sim.Ftp.Path(tc.remote))
+		if err != nil {
+			t.Errorf("%s not at %s: %v", tc.name, tc.remote, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", tc.remote, got, content)
+		}
+	}
+	err := olt.UploadConfig(writeTemp(t, "upload.txt", "neither a script nor an Innbox config\n"))
+	if !errors.Is(err, goPon.ErrNotInput) {
+		t.Errorf("upload.txt: got %v, want ErrNotInput", err)
+	}
+}
+
+func TestDeleteConfig(t *testing.T) {
+	sim, olt := newFtpOlt(t)
+	for _, remote := range []string{"/config/delete.scr", "/config/onu-default/delete.conf"} {
+		err := ioutil.WriteFile(sim.Ftp.Path(remote), []byte("to delete\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// any leading path is dropped, only the name and extension select the file
+		err = olt.DeleteConfig(filepath.Join("some", "local", "dir", filepath.Base(remote)))
+		if err != nil {
+			t.Errorf("%s: %v", remote, err)
+		}
+		if _, err := os.Stat(sim.Ftp.Path(remote)); !os.IsNotExist(err) {
+			t.Errorf("%s still on the server", remote)
+		}
+	}
+	err := olt.DeleteConfig("missing.scr")
+	if err == nil {
+		t.Error("deleting a file that is not on the server succeeded")
+	}
+}
+
+func TestFtpBadLogin(t *testing.T) {
+	sim, olt := newFtpOlt(t)
+	olt.SetCredentials(goPon.NewCredentials(olt.Credentials.Username, "wrong"+olt.Credentials.Password))
+	err := olt.GetCurrentLogs(t.TempDir())
+	if err == nil {
+		t.Error("GetCurrentLogs with a bad login succeeded")
+	}
+	err = olt.UploadConfig(writeTemp(t, "refused.scr", "refused\n"))
+	if err == nil {
+		t.Error("UploadConfig with a bad login succeeded")
+	}
+	if _, err := os.Stat(sim.Ftp.Path("/config/refused.scr")); err == nil {
+		t.Error("refused.scr was stored with a bad login")
+	}
+	err = ioutil.WriteFile(sim.Ftp.Path("/config/kept.scr"), []byte("kept\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = olt.DeleteConfig("kept.scr")
+	if err == nil {
+		t.Error("DeleteConfig with a bad login succeeded")
+	}
+	if _, err := os.Stat(sim.Ftp.Path("/config/kept.scr")); err != nil {
+		t.Error("kept.scr was deleted with a bad login")
+	}
+}
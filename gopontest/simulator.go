@@ -0,0 +1,874 @@
+// Package gopontest provides an in-memory Lumia OLT that serves the ISKRATEL-MSAN-MIB Restconf tree,
+// so the goPon library and cmd workflow can be exercised without access to real hardware
+package gopontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lindsaybb/goPon"
+)
+
+const (
+	mibRoot = "ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB"
+
+	serviceProfiles  = "msanServiceProfileTable"
+	flowProfiles     = "msanServiceFlowProfileTable"
+	vlanProfiles     = "msanVlanProfileTable"
+	igmpProfiles     = "msanMulticastProfileTable"
+	securityProfiles = "msanSecurityProfileTable"
+	onuFlowProfiles  = "msanOnuFlowProfileTable"
+	onuTcontProfiles = "msanOnuTcontProfileTable"
+	onuVlanProfiles  = "msanOnuVlanProfileTable"
+	onuVlanRules     = "msanOnuVlanProfileRuleTable"
+	onuIgmpProfiles  = "msanOnuMulticastProfileTable"
+	l2cpProfiles     = "msanL2cpProfileTable"
+	onuBlacklist     = "msanOnuBlackListTable"
+	onuConfig        = "msanOnuCfgTable"
+	onuProfiles      = "msanServicePortProfileTable"
+	onuInfo          = "msanOnuInfoTable"
+)
+
+// Blacklist causes reported by the simulator, matching OnuBlacklist.GetBlCause
+const (
+	CauseSnNotKnown       = goPon.BlCauseSnNotKnown
+	CausePasswordMismatch = goPon.BlCausePasswordMismatch
+	CausePonLinkMismatch  = goPon.BlCausePonLinkMismatch
+)
+
+// table describes how the simulator keys and maintains one Restconf table
+type table struct {
+	entry    string   // name of the list inside the table
+	keys     []string // leaves that form the entry key, in Url order
+	usage    string   // leaf holding the usage counter, if any
+	readOnly bool     // table is derived from the Onu state and cannot be written
+}
+
+var tables = map[string]*table{
+	serviceProfiles:  {entry: "msanServiceProfileEntry", keys: []string{"msanServiceProfileName"}, usage: "msanServiceProfileUsage"},
+	flowProfiles:     {entry: "msanServiceFlowProfileEntry", keys: []string{"msanServiceFlowProfileName"}, usage: "msanServiceFlowProfileUsage"},
+	vlanProfiles:     {entry: "msanVlanProfileEntry", keys: []string{"msanVlanProfileName"}, usage: "msanVlanProfileUsage"},
+	igmpProfiles:     {entry: "msanMulticastProfileEntry", keys: []string{"msanMulticastProfileName"}, usage: "msanMulticastProfileUsage"},
+	securityProfiles: {entry: "msanSecurityProfileEntry", keys: []string{"msanSecurityProfileName"}, usage: "msanSecurityProfileUsage"},
+	onuFlowProfiles:  {entry: "msanOnuFlowProfileEntry", keys: []string{"msanOnuFlowProfileName"}, usage: "msanOnuFlowProfileUsage"},
+	onuTcontProfiles: {entry: "msanOnuTcontProfileEntry", keys: []string{"msanOnuTcontProfileName"}, usage: "msanOnuTcontProfileUsage"},
+	onuVlanProfiles:  {entry: "msanOnuVlanProfileEntry", keys: []string{"msanOnuVlanProfileName"}, usage: "msanOnuVlanProfileUsage"},
+	onuVlanRules:     {entry: "msanOnuVlanProfileRuleEntry", keys: []string{"msanOnuVlanProfileName", "msanOnuVlanProfileRuleId"}},
+	onuIgmpProfiles:  {entry: "msanOnuMulticastProfileEntry", keys: []string{"msanOnuMulticastProfileName"}, usage: "msanOnuMulticastProfileUsage"},
+	l2cpProfiles:     {entry: "msanL2cpProfileEntry", keys: []string{"msanL2cpProfileName"}, usage: "msanL2cpProfileUsage"},
+	onuBlacklist:     {entry: "msanOnuBlackListEntry", keys: []string{"msanOnuBlackListIfName", "msanOnuBlackListSerialNumber"}, readOnly: true},
+	onuConfig:        {entry: "msanOnuCfgEntry", keys: []string{"msanOnuCfgIfName"}},
+	onuProfiles:      {entry: "msanServicePortProfileEntry", keys: []string{"ifName", "msanServiceProfileName"}},
+	onuInfo:          {entry: "msanOnuInfoEntry", keys: []string{"msanOnuInfoIfName"}, readOnly: true},
+}
+
+// serviceProfileRefs maps each reference leaf of a Service Profile to the table it points into
+var serviceProfileRefs = map[string]string{
+	"msanServiceProfileServiceFlowProfileName":  flowProfiles,
+	"msanServiceProfileMulticastProfileName":    igmpProfiles,
+	"msanServiceProfileVlanProfileName":         vlanProfiles,
+	"msanServiceProfileL2cpProfileName":         l2cpProfiles,
+	"msanServiceProfileSecurityProfileName":     securityProfiles,
+	"msanServiceProfileOnuFlowProfileName":      onuFlowProfiles,
+	"msanServiceProfileOnuVlanProfileName":      onuVlanProfiles,
+	"msanServiceProfileOnuMulticastProfileName": onuIgmpProfiles,
+	"msanServiceProfileOnuTcontProfileName":     onuTcontProfiles,
+}
+
+type entry map[string]interface{}
+
+// Onu is a device attached to a port of the simulated OLT. It appears on the Blacklist
+// until an OnuConfig on the same port authorizes its SerialNumber
+type Onu struct {
+	Port         string // olt port 0/x the device is connected to
+	SerialNumber string
+	Password     string
+	EquipmentID  string
+	Version      string
+	RxPower      int // raw values as reported in msanOnuInfoTable
+	TxPower      int
+	OltRxPower   int
+	Temp         int
+}
+
+// Simulator is a Restconf server holding the state of a single OLT
+type Simulator struct {
+	Server      *httptest.Server
+	Credentials *goPon.Credentials // login accepted in the session cookie
+	Ftp         *FtpServer         // started by StartFtp
+	mu          sync.Mutex
+	data        map[string][]entry
+	onus        []*Onu
+	faults      []*fault
+}
+
+// fault fails the requests it matches until its count runs out
+type fault struct {
+	method string
+	tbl    string
+	match  string
+	count  int
+}
+
+// NewSimulator starts an empty simulator accepting the factory default login
+func NewSimulator() *Simulator {
+	s := &Simulator{
+		Credentials: goPon.DefaultCredentials(),
+		data:        make(map[string][]entry),
+	}
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+// Host returns the address of the simulator in the form accepted by NewLumiaOlt
+func (s *Simulator) Host() string {
+	return s.Server.Listener.Addr().String()
+}
+
+// Close shuts the simulator and any Ftp server down
+func (s *Simulator) Close() {
+	s.Server.Close()
+	if s.Ftp != nil {
+		s.Ftp.Close()
+	}
+}
+
+// StartFtp starts an FtpServer alongside the simulator accepting the same login
+func (s *Simulator) StartFtp() (*FtpServer, error) {
+	f, err := NewFtpServer()
+	if err != nil {
+		return nil, err
+	}
+	f.Credentials = s.Credentials
+	s.Ftp = f
+	return f, nil
+}
+
+// Olt returns a LumiaOlt pointed at the simulator, trusting its certificate and with request printing disabled.
+// If StartFtp was called the Ftp operations of the LumiaOlt use that server
+func (s *Simulator) Olt() *goPon.LumiaOlt {
+	olt := goPon.NewLumiaOltWithCredentials(s.Host(), goPon.NewCredentials(s.Credentials.Username, s.Credentials.Password))
+	olt.Client.SetTLSConfig(s.Server.Client().Transport.(*http.Transport).TLSClientConfig.Clone())
+	olt.Client.Verbose = false
+	if s.Ftp != nil {
+		olt.FtpHost = s.Ftp.Host()
+	}
+	return olt
+}
+
+// AddOnu attaches a device to the supplied olt port (0/x); it will show on the Blacklist until authorized
+func (s *Simulator) AddOnu(port, sn, password string) *Onu {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	onu := &Onu{
+		Port:         port,
+		SerialNumber: sn,
+		Password:     password,
+		EquipmentID:  "innbox G22",
+		Version:      "1.0",
+		RxPower:      -2150,
+		TxPower:      250,
+		OltRxPower:   -2300,
+		Temp:         45,
+	}
+	s.onus = append(s.onus, onu)
+	return onu
+}
+
+// RemoveOnu detaches the device with the supplied serial number, leaving any OnuConfig in place
+func (s *Simulator) RemoveOnu(sn string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, onu := range s.onus {
+		if onu.SerialNumber == sn {
+			s.onus = append(s.onus[:i], s.onus[i+1:]...)
+			return nil
+		}
+	}
+	return goPon.ErrNotExists
+}
+
+// Seed inserts goPon objects into their tables as if they had been Posted, applying the same validation.
+// Supported are pointers to every profile type along with OnuConfig and OnuProfile
+func (s *Simulator) Seed(objects ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, o := range objects {
+		tbl, ok := tableOf(o)
+		if !ok {
+			return fmt.Errorf("%w: %T", goPon.ErrNotStruct, o)
+		}
+		data, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		var m entry
+		err = json.Unmarshal(data, &m)
+		if err != nil {
+			return err
+		}
+		if tbl == onuConfig {
+			_, err = s.patchOnuConfig(str(m["msanOnuCfgIfName"]), m)
+		} else {
+			_, err = s.create(tbl, nil, m)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableOf returns the table a goPon object is stored in
+func tableOf(o interface{}) (string, bool) {
+	switch o.(type) {
+	case *goPon.ServiceProfile:
+		return serviceProfiles, true
+	case *goPon.FlowProfile:
+		return flowProfiles, true
+	case *goPon.VlanProfile:
+		return vlanProfiles, true
+	case *goPon.IgmpProfile:
+		return igmpProfiles, true
+	case *goPon.SecurityProfile:
+		return securityProfiles, true
+	case *goPon.OnuFlowProfile:
+		return onuFlowProfiles, true
+	case *goPon.OnuTcontProfile:
+		return onuTcontProfiles, true
+	case *goPon.OnuVlanProfile:
+		return onuVlanProfiles, true
+	case *goPon.OnuVlanRule:
+		return onuVlanRules, true
+	case *goPon.OnuIgmpProfile:
+		return onuIgmpProfiles, true
+	case *goPon.L2cpProfile:
+		return l2cpProfiles, true
+	case *goPon.OnuConfig:
+		return onuConfig, true
+	case *goPon.OnuProfile:
+		return onuProfiles, true
+	}
+	return "", false
+}
+
+// Entries returns a copy of the current entries of a table, including the derived Blacklist and Info tables
+func (s *Simulator) Entries(tbl string) []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []map[string]interface{}
+	for _, e := range s.table(tbl) {
+		list = append(list, e.copy())
+	}
+	return list
+}
+
+// simError is a Restconf error with the status it is returned with
+type simError struct {
+	code    int
+	errType string
+	tag     string
+	path    string
+	msg     string
+}
+
+func (e *simError) Error() string {
+	return e.msg
+}
+
+func newSimError(code int, tag, path, format string, a ...interface{}) *simError {
+	errType := "application"
+	if code == http.StatusUnauthorized || code == http.StatusMethodNotAllowed {
+		errType = "protocol"
+	}
+	return &simError{code: code, errType: errType, tag: tag, path: path, msg: fmt.Sprintf(format, a...)}
+}
+
+// writeError sends the RFC 8040 error payload for err
+func writeError(w http.ResponseWriter, err error) {
+	se, ok := err.(*simError)
+	if !ok {
+		se = newSimError(http.StatusInternalServerError, "operation-failed", "", "%v", err)
+	}
+	body := map[string]interface{}{
+		"ietf-restconf:errors": map[string]interface{}{
+			"error": []map[string]string{{
+				"error-type":    se.errType,
+				"error-tag":     se.tag,
+				"error-path":    se.path,
+				"error-message": se.msg,
+			}},
+		},
+	}
+	w.Header().Set("Content-Type", "application/yang-data+json")
+	w.WriteHeader(se.code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// ServeHTTP handles a Restconf request against the ISKRATEL-MSAN-MIB tree
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Cookie") != s.Credentials.Cookie() {
+		writeError(w, newSimError(http.StatusUnauthorized, "access-denied", "", "invalid session"))
+		return
+	}
+	tbl, key, err := parsePath(r.URL.EscapedPath())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.injectFault(r.Method, tbl, key, data) {
+		writeError(w, newSimError(http.StatusInternalServerError, "operation-failed", tbl, "injected fault"))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		var body interface{}
+		body, err = s.get(tbl, key)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yang-data+json")
+		json.NewEncoder(w).Encode(body)
+		return
+	case http.MethodPost, http.MethodPatch:
+		if key == nil {
+			writeError(w, newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "%s requires an entry key", r.Method))
+			return
+		}
+		var m entry
+		if e := json.Unmarshal(data, &m); e != nil || m == nil {
+			writeError(w, newSimError(http.StatusBadRequest, "malformed-message", tbl, "invalid body: %v", e))
+			return
+		}
+		if r.Method == http.MethodPost {
+			_, err = s.create(tbl, key, m)
+			if err == nil {
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+		} else {
+			err = s.patch(tbl, key, m)
+		}
+	case http.MethodDelete:
+		if key == nil {
+			writeError(w, newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "DELETE requires an entry key"))
+			return
+		}
+		err = s.delete(tbl, key)
+	default:
+		err = newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "method %s not supported", r.Method)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FailRequests makes the next count requests with the method to the table fail with 500 operation-failed.
+// An empty method or table matches any, and a non-empty match must appear in the entry key or the request body,
+// so a single Onu or Service Profile can be made to fail in the middle of a batch
+func (s *Simulator) FailRequests(method, tbl, match string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, tbl: tbl, match: match, count: count})
+}
+
+// injectFault reports whether a request matches a fault, using up one of its count
+func (s *Simulator) injectFault(method, tbl string, key []string, body []byte) bool {
+	for i, f := range s.faults {
+		if f.method != "" && f.method != method || f.tbl != "" && f.tbl != tbl {
+			continue
+		}
+		if f.match != "" && !strings.Contains(strings.Join(key, ","), f.match) && !strings.Contains(string(body), f.match) {
+			continue
+		}
+		f.count--
+		if f.count < 1 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return true
+	}
+	return false
+}
+
+// parsePath splits a Restconf Url path into the table and the decoded entry key, either of which may be empty
+func parsePath(path string) (tbl string, key []string, err error) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) < 3 || segs[0] != "restconf" || segs[1] != "data" {
+		return "", nil, newSimError(http.StatusNotFound, "invalid-value", path, "unknown resource")
+	}
+	root, _ := url.PathUnescape(segs[2])
+	if root != mibRoot || len(segs) > 5 {
+		return "", nil, newSimError(http.StatusNotFound, "invalid-value", path, "unknown resource")
+	}
+	if len(segs) < 4 {
+		return "", nil, nil
+	}
+	tbl = segs[3]
+	t, ok := tables[tbl]
+	if !ok {
+		return "", nil, newSimError(http.StatusNotFound, "invalid-value", tbl, "unknown table")
+	}
+	if len(segs) < 5 {
+		return tbl, nil, nil
+	}
+	kv := strings.SplitN(segs[4], "=", 2)
+	if len(kv) != 2 || kv[0] != t.entry || kv[1] == "" {
+		return "", nil, newSimError(http.StatusBadRequest, "invalid-value", segs[4], "expected %s=<key>", t.entry)
+	}
+	for _, k := range strings.Split(kv[1], ",") {
+		k, err = url.PathUnescape(k)
+		if err != nil {
+			return "", nil, newSimError(http.StatusBadRequest, "invalid-value", segs[4], "%v", err)
+		}
+		key = append(key, k)
+	}
+	if len(key) > len(t.keys) {
+		return "", nil, newSimError(http.StatusBadRequest, "invalid-value", segs[4], "too many key values")
+	}
+	return tbl, key, nil
+}
+
+// get returns the requested resource wrapped in the same containers as the OLT
+func (s *Simulator) get(tbl string, key []string) (interface{}, error) {
+	mib := make(map[string]interface{})
+	if tbl == "" {
+		for name, t := range tables {
+			mib[name] = map[string]interface{}{t.entry: s.table(name)}
+		}
+	} else {
+		list := s.table(tbl)
+		if key != nil {
+			i := s.find(tbl, key)
+			if i < 0 {
+				return nil, newSimError(http.StatusNotFound, "data-missing", tbl, "entry %s does not exist", strings.Join(key, ","))
+			}
+			list = []entry{list[i]}
+		}
+		mib[tbl] = map[string]interface{}{tables[tbl].entry: list}
+	}
+	return map[string]interface{}{
+		"ISKRATEL-MSAN-MIB:": map[string]interface{}{
+			"ISKRATEL-MSAN-MIB": mib,
+		},
+	}, nil
+}
+
+// table returns the entries of a table, building the Blacklist and Info tables from the Onu state
+func (s *Simulator) table(tbl string) []entry {
+	switch tbl {
+	case onuBlacklist:
+		return s.blacklist()
+	case onuInfo:
+		return s.info()
+	}
+	if s.data[tbl] == nil {
+		return []entry{}
+	}
+	return s.data[tbl]
+}
+
+// find returns the index of the entry matching key, which may be a prefix of the table keys, or -1
+func (s *Simulator) find(tbl string, key []string) int {
+	t := tables[tbl]
+	for i, e := range s.table(tbl) {
+		match := true
+		for n, k := range key {
+			if str(e[t.keys[n]]) != k {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// create validates and inserts a new entry; key leaves missing from the body are taken from the Url
+func (s *Simulator) create(tbl string, key []string, m entry) (entry, error) {
+	t := tables[tbl]
+	if t.readOnly {
+		return nil, newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "table is read-only")
+	}
+	if tbl == onuConfig {
+		if len(key) > 0 {
+			if i := s.find(tbl, key); i >= 0 {
+				return nil, newSimError(http.StatusConflict, "data-exists", tbl, "entry %s already exists", key[0])
+			}
+			m[t.keys[0]] = key[0]
+		}
+		return s.patchOnuConfig(str(m[t.keys[0]]), m)
+	}
+	var rules []interface{}
+	if tbl == onuVlanProfiles {
+		// rules nested by OnuVlanProfile.GenerateJson are held in their own table
+		if nested, ok := m["Rules"].(map[string]interface{}); ok {
+			rules, _ = nested["Entry"].([]interface{})
+		}
+		delete(m, "Rules")
+	}
+	full, err := fillKey(tbl, key, m)
+	if err != nil {
+		return nil, err
+	}
+	if s.find(tbl, full) >= 0 {
+		return nil, newSimError(http.StatusConflict, "data-exists", tbl, "entry %s already exists", strings.Join(full, ","))
+	}
+	err = s.validate(tbl, m)
+	if err != nil {
+		return nil, err
+	}
+	s.data[tbl] = append(s.data[tbl], m)
+	for _, r := range rules {
+		rm, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rm[tables[onuVlanRules].keys[0]] = full[0]
+		_, err = s.create(onuVlanRules, nil, rm)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.updateUsage()
+	return m, nil
+}
+
+// fillKey completes the key leaves of m from the Url key and returns the full key of the entry
+func fillKey(tbl string, key []string, m entry) ([]string, error) {
+	t := tables[tbl]
+	var full []string
+	for n, leaf := range t.keys {
+		v := str(m[leaf])
+		if n < len(key) {
+			if v != "" && v != key[n] {
+				return nil, newSimError(http.StatusBadRequest, "invalid-value", leaf, "%s does not match the entry key %s", v, key[n])
+			}
+			if v == "" {
+				m[leaf] = key[n]
+				v = key[n]
+			}
+		}
+		if v == "" {
+			return nil, newSimError(http.StatusBadRequest, "missing-element", leaf, "%s is required", leaf)
+		}
+		full = append(full, v)
+	}
+	return full, nil
+}
+
+// validate checks that every profile referenced by the entry exists
+func (s *Simulator) validate(tbl string, m entry) error {
+	switch tbl {
+	case serviceProfiles:
+		for leaf, ref := range serviceProfileRefs {
+			if name := str(m[leaf]); name != "" && s.find(ref, []string{name}) < 0 {
+				return newSimError(http.StatusBadRequest, "invalid-value", leaf, "%s %s does not exist", tables[ref].entry, name)
+			}
+		}
+	case onuVlanRules:
+		if name := str(m["msanOnuVlanProfileName"]); s.find(onuVlanProfiles, []string{name}) < 0 {
+			return newSimError(http.StatusBadRequest, "invalid-value", "msanOnuVlanProfileName", "onu vlan profile %s does not exist", name)
+		}
+	case onuProfiles:
+		if name := str(m["msanServiceProfileName"]); s.find(serviceProfiles, []string{name}) < 0 {
+			return newSimError(http.StatusBadRequest, "invalid-value", "msanServiceProfileName", "service profile %s does not exist", name)
+		}
+		if intf := str(m["ifName"]); s.find(onuConfig, []string{intf}) < 0 {
+			return newSimError(http.StatusBadRequest, "invalid-value", "ifName", "onu interface %s is not configured", intf)
+		}
+	}
+	return nil
+}
+
+// patch merges the body into an existing entry; the OnuConfig table is created on demand like the OLT
+func (s *Simulator) patch(tbl string, key []string, m entry) error {
+	t := tables[tbl]
+	if t.readOnly {
+		return newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "table is read-only")
+	}
+	if tbl == onuConfig {
+		_, err := s.patchOnuConfig(key[0], m)
+		return err
+	}
+	if len(key) != len(t.keys) {
+		return newSimError(http.StatusBadRequest, "invalid-value", tbl, "incomplete entry key")
+	}
+	i := s.find(tbl, key)
+	if i < 0 {
+		return newSimError(http.StatusNotFound, "data-missing", tbl, "entry %s does not exist", strings.Join(key, ","))
+	}
+	delete(m, "Rules")
+	merged := s.data[tbl][i].copy()
+	for k, v := range m {
+		merged[k] = v
+	}
+	full, err := fillKey(tbl, key, merged)
+	if err != nil {
+		return err
+	}
+	if strings.Join(full, ",") != strings.Join(key, ",") {
+		return newSimError(http.StatusBadRequest, "invalid-value", tbl, "entry key cannot be changed")
+	}
+	err = s.validate(tbl, merged)
+	if err != nil {
+		return err
+	}
+	s.data[tbl][i] = merged
+	s.updateUsage()
+	return nil
+}
+
+// patchOnuConfig writes the OnuConfig of an interface. A blank serial number and password
+// removes the configuration, returning any attached device to the Blacklist
+func (s *Simulator) patchOnuConfig(intf string, m entry) (entry, error) {
+	const (
+		ifLeaf = "msanOnuCfgIfName"
+		snLeaf = "msanOnuCfgSerialNumber"
+		pwLeaf = "msanOnuCfgPassword"
+	)
+	if err := validOnuInterface(intf); err != nil {
+		return nil, err
+	}
+	if v := str(m[ifLeaf]); v != "" && v != intf {
+		return nil, newSimError(http.StatusBadRequest, "invalid-value", ifLeaf, "%s does not match the entry key %s", v, intf)
+	}
+	m[ifLeaf] = intf
+	i := s.find(onuConfig, []string{intf})
+	merged := entry{}
+	if i >= 0 {
+		merged = s.data[onuConfig][i].copy()
+	}
+	for k, v := range m {
+		merged[k] = v
+	}
+	sn := str(merged[snLeaf])
+	if sn == "" && str(merged[pwLeaf]) == "" {
+		if i >= 0 {
+			s.data[onuConfig] = append(s.data[onuConfig][:i], s.data[onuConfig][i+1:]...)
+		}
+		return nil, nil
+	}
+	if sn != "" {
+		for _, e := range s.data[onuConfig] {
+			if str(e[snLeaf]) == sn && str(e[ifLeaf]) != intf {
+				return nil, newSimError(http.StatusConflict, "data-exists", snLeaf, "serial number %s is configured on %s", sn, str(e[ifLeaf]))
+			}
+		}
+	}
+	if i >= 0 {
+		s.data[onuConfig][i] = merged
+	} else {
+		s.data[onuConfig] = append(s.data[onuConfig], merged)
+	}
+	return merged, nil
+}
+
+// validOnuInterface checks the interface is of the form 0/x/y with a subinterface between 1 and 128
+func validOnuInterface(intf string) error {
+	parts := strings.Split(intf, "/")
+	if len(parts) != 3 {
+		return newSimError(http.StatusBadRequest, "invalid-value", intf, "invalid onu interface")
+	}
+	for _, p := range parts {
+		if _, err := strconv.Atoi(p); err != nil {
+			return newSimError(http.StatusBadRequest, "invalid-value", intf, "invalid onu interface")
+		}
+	}
+	if sub, _ := strconv.Atoi(parts[2]); sub < 1 || sub > 128 {
+		return newSimError(http.StatusBadRequest, "invalid-value", intf, "onu subinterface out of range 1-128")
+	}
+	return nil
+}
+
+// delete removes an entry, refusing profiles that are in use
+func (s *Simulator) delete(tbl string, key []string) error {
+	t := tables[tbl]
+	if t.readOnly {
+		return newSimError(http.StatusMethodNotAllowed, "operation-not-supported", tbl, "table is read-only")
+	}
+	if len(key) != len(t.keys) {
+		return newSimError(http.StatusBadRequest, "invalid-value", tbl, "incomplete entry key")
+	}
+	i := s.find(tbl, key)
+	if i < 0 {
+		return newSimError(http.StatusNotFound, "data-missing", tbl, "entry %s does not exist", strings.Join(key, ","))
+	}
+	if t.usage != "" && toInt(s.data[tbl][i][t.usage]) == 1 {
+		return newSimError(http.StatusConflict, "in-use", tbl, "entry %s is in use", key[0])
+	}
+	if tbl == onuVlanRules {
+		name := key[0]
+		if p := s.find(onuVlanProfiles, []string{name}); p >= 0 && toInt(s.data[onuVlanProfiles][p]["msanOnuVlanProfileUsage"]) == 1 {
+			return newSimError(http.StatusConflict, "in-use", tbl, "onu vlan profile %s is in use", name)
+		}
+	}
+	s.data[tbl] = append(s.data[tbl][:i], s.data[tbl][i+1:]...)
+	if tbl == onuVlanProfiles {
+		var kept []entry
+		for _, r := range s.data[onuVlanRules] {
+			if str(r["msanOnuVlanProfileName"]) != key[0] {
+				kept = append(kept, r)
+			}
+		}
+		s.data[onuVlanRules] = kept
+	}
+	s.updateUsage()
+	return nil
+}
+
+// updateUsage recalculates the usage counter of every profile: 1 when referenced, 2 when unused
+func (s *Simulator) updateUsage() {
+	used := make(map[string]map[string]bool)
+	mark := func(tbl, name string) {
+		if used[tbl] == nil {
+			used[tbl] = make(map[string]bool)
+		}
+		used[tbl][name] = true
+	}
+	for _, sp := range s.data[serviceProfiles] {
+		for leaf, ref := range serviceProfileRefs {
+			if name := str(sp[leaf]); name != "" {
+				mark(ref, name)
+			}
+		}
+	}
+	for _, b := range s.data[onuProfiles] {
+		mark(serviceProfiles, str(b["msanServiceProfileName"]))
+	}
+	for name, t := range tables {
+		if t.usage == "" {
+			continue
+		}
+		for _, e := range s.data[name] {
+			if used[name][str(e[t.keys[0]])] {
+				e[t.usage] = 1
+			} else {
+				e[t.usage] = 2
+			}
+		}
+	}
+}
+
+// onuStatus reports the interface an attached device is authorized on, or the Blacklist cause
+func (s *Simulator) onuStatus(onu *Onu) (cfg entry, cause int) {
+	for _, e := range s.data[onuConfig] {
+		if str(e["msanOnuCfgSerialNumber"]) != onu.SerialNumber {
+			continue
+		}
+		if !strings.HasPrefix(str(e["msanOnuCfgIfName"]), onu.Port+"/") {
+			return nil, CausePonLinkMismatch
+		}
+		if pw := str(e["msanOnuCfgPassword"]); pw != "" && pw != onu.Password {
+			return nil, CausePasswordMismatch
+		}
+		return e, 0
+	}
+	// a config without a serial number accepts any device on its port presenting the password
+	if onu.Password != "" {
+		for _, e := range s.data[onuConfig] {
+			if str(e["msanOnuCfgSerialNumber"]) == "" && str(e["msanOnuCfgPassword"]) == onu.Password && strings.HasPrefix(str(e["msanOnuCfgIfName"]), onu.Port+"/") {
+				return e, 0
+			}
+		}
+	}
+	return nil, CauseSnNotKnown
+}
+
+// blacklist lists the attached devices that are not authorized
+func (s *Simulator) blacklist() []entry {
+	list := []entry{}
+	for _, onu := range s.onus {
+		if _, cause := s.onuStatus(onu); cause != 0 {
+			list = append(list, entry{
+				"msanOnuBlackListIfName":       onu.Port,
+				"msanOnuBlackListSerialNumber": onu.SerialNumber,
+				"msanOnuBlackListPassword":     onu.Password,
+				"msanOnuBlackListCause":        cause,
+			})
+		}
+	}
+	return list
+}
+
+// info lists an entry for every configured interface, populated from the device when it is active
+func (s *Simulator) info() []entry {
+	list := []entry{}
+	active := make(map[string]*Onu)
+	for _, onu := range s.onus {
+		if cfg, _ := s.onuStatus(onu); cfg != nil {
+			active[str(cfg["msanOnuCfgIfName"])] = onu
+		}
+	}
+	for _, cfg := range s.data[onuConfig] {
+		intf := str(cfg["msanOnuCfgIfName"])
+		e := entry{
+			"msanOnuInfoIfName":       intf,
+			"msanOnuInfoSerialNumber": str(cfg["msanOnuCfgSerialNumber"]),
+			"msanOnuInfoPassword":     str(cfg["msanOnuCfgPassword"]),
+			"msanOnuInfoOperState":    2,
+		}
+		if onu, ok := active[intf]; ok {
+			e["msanOnuInfoSerialNumber"] = onu.SerialNumber
+			if len(onu.SerialNumber) > 4 {
+				e["msanOnuInfoVendorId"] = onu.SerialNumber[:4]
+			}
+			e["msanOnuInfoVersion"] = onu.Version
+			e["msanOnuInfoEquipmentId"] = onu.EquipmentID
+			e["msanOnuInfoRxPower"] = onu.RxPower
+			e["msanOnuInfoTxPower"] = onu.TxPower
+			e["msanOnuInfoOltRxPower"] = onu.OltRxPower
+			e["msanOnuInfoTemp"] = onu.Temp
+			if toInt(cfg["msanOnuCfgAdminState"]) != 2 {
+				e["msanOnuInfoOperState"] = 1
+			}
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return str(list[i]["msanOnuInfoIfName"]) < str(list[j]["msanOnuInfoIfName"])
+	})
+	return list
+}
+
+func (e entry) copy() entry {
+	c := make(entry, len(e))
+	for k, v := range e {
+		c[k] = v
+	}
+	return c
+}
+
+// str renders a leaf value as it appears in an entry key
+func str(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toInt(v interface{}) int {
+	i, _ := strconv.Atoi(str(v))
+	return i
+}
@@ -50,10 +50,10 @@ func (p *IgmpProfile) Copy(newName string) (*IgmpProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 func (p *IgmpProfile) GetIgmpSnooping() bool {
@@ -19,6 +19,21 @@ func (p *L2cpProfile) GetName() string {
 	return p.Name
 }
 
+func (p *L2cpProfile) IsUsed() bool {
+	return p.Usage == 1
+}
+
+// Copy returns a copy of the profile object with a new name and Usage set to 2
+func (p *L2cpProfile) Copy(newName string) (*L2cpProfile, error) {
+	if p.Name == newName {
+		return nil, ErrExists
+	}
+	np := *p
+	np.Name = newName
+	np.Usage = 2
+	return &np, nil
+}
+
 // GenerateJson serializes the data structure so it can be set with Restconf
 func (p *L2cpProfile) GenerateJson() (name string, data []byte) {
 	data, err := json.Marshal(p)
@@ -2,34 +2,68 @@ package goPon
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
+	"log"
+	"net"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 )
 
 type LumiaOlt struct {
-	Host         string        // ip address or domain name
-	Current      *IskratelMsan // last updated complete data structure
-	Cache        *IskratelMsan // last changed complete data structure
-	Registration []*OnuRegister
+	Host         string          // ip address or domain name
+	FtpHost      string          // ftp address with optional :port, defaults to Host on port 21
+	Credentials  *Credentials    // login used for Restconf and Ftp
+	Client       *RestconfClient // pooled Restconf connection to Host
+	Current      *IskratelMsan   // last updated complete data structure, replaced as a whole by each Get
+	Cache        *IskratelMsan   // last changed complete data structure
+	Registration []*OnuRegister  // guarded by the Registry methods while a bulk operation runs
+	Graph        *ProfileGraph   // consulted by the Delete methods and kept current by the Post, Patch and Delete methods when set, see GetProfileGraph
+	Concurrency  int             // requests kept in flight by bulk operations, DefaultConcurrency unless changed
+	Progress     BulkProgress    // called as each item of a bulk operation completes, if set
+	Store        RegistryStore   // keeps Registration between runs when set, see OpenRegistryStore
+	WalledGarden string          // Service Profile bound by SuspendOnu in place of the services of the Onu, if set
+	treeMu       sync.Mutex      // guards Current and Cache
+	regMu        sync.Mutex      // guards Registration and reserved
+	reserved     map[string]bool // Onu interfaces handed out by NextAvailableOnuInterface but not yet in Registration
 }
 
 type OnuRegister struct {
-	SerialNumber string   // onu serialNumber
-	Interface    string   // onu interface 0/x/y
-	Services     []string // []string Service Profile names
-	// additional items like Model, SW Version can be collected here
+	SerialNumber string    `json:"serialNumber,omitempty"` // onu serialNumber, empty when authorized by password only
+	Password     string    `json:"password,omitempty"`     // password (registration ID) the onu must present, empty to authorize by serialNumber alone
+	Interface    string    `json:"interface,omitempty"`    // onu interface 0/x/y
+	Services     []string  `json:"services,omitempty"`     // []string Service Profile names
+	SubscriberID string    `json:"subscriberId,omitempty"` // account the onu is installed for
+	InstallDate  time.Time `json:"installDate"`            // when the onu was first found configured
+	Model        string    `json:"model,omitempty"`
+	Notes        string    `json:"notes,omitempty"`
+	State        string    `json:"state,omitempty"` // StatePreProvisioned, StateActive, StateSuspended or StateRemoved
+	// Service Profile bindings recorded by SuspendOnu, restored by ResumeOnu
+	SuspendedServices []string `json:"suspendedServices,omitempty"`
+	// walled garden Service Profile bound by SuspendOnu, empty when the Onu is suspended by its AdminState
+	WalledGarden string `json:"walledGarden,omitempty"`
 }
 
 var OnuRegisterHeaders = []string{
 	"Interface",
 	"Serial Number",
+	"Password",
 	"Service Profiles",
+	"State",
+	"Subscriber",
+}
+
+// registerKey identifies an Onu of the Registration index: the Serial Number, or the password when authorized by password only
+func registerKey(sn, password string) string {
+	if sn != "" {
+		return sn
+	}
+	return "pw:" + password
 }
 
 func (o *OnuRegister) ConcatServices() string {
@@ -40,16 +74,64 @@ func (o *OnuRegister) ConcatServices() string {
 	return services
 }
 
-// NewIskratelMsan sets up a data structure for the specified Host
+// NewLumiaOlt sets up a data structure for the specified Host.
+// Credentials are taken from the environment (see CredentialsFromEnv), falling back to the factory default
 func NewLumiaOlt(host string) *LumiaOlt {
+	cred, err := CredentialsFromEnv()
+	if err != nil {
+		log.Printf("lumiaOlt: %v, using default credentials\n", err)
+		cred = DefaultCredentials()
+	}
+	return NewLumiaOltWithCredentials(host, cred)
+}
+
+// NewLumiaOltWithCredentials sets up a data structure for the specified Host using the supplied Credentials
+func NewLumiaOltWithCredentials(host string, cred *Credentials) *LumiaOlt {
+	if cred == nil {
+		cred = DefaultCredentials()
+	}
 	var t = &LumiaOlt{
-		Host:    host,
-		Current: NewIskratelMsan(),
-		Cache:   NewIskratelMsan(),
+		Host:        host,
+		Credentials: cred,
+		Client:      NewRestconfClient(host, cred),
+		Current:     NewIskratelMsan(),
+		Cache:       NewIskratelMsan(),
+		Concurrency: DefaultConcurrency,
+		reserved:    make(map[string]bool),
 	}
 	return t
 }
 
+// SetCredentials replaces the login used for all further Restconf and Ftp interactions
+func (l *LumiaOlt) SetCredentials(cred *Credentials) {
+	if cred == nil {
+		cred = DefaultCredentials()
+	}
+	l.Credentials = cred
+	l.Client.Credentials = cred
+}
+
+// LoadCredentialsFile reads the login from the supplied file (see LoadCredentialsFile) and applies it to the OLT
+func (l *LumiaOlt) LoadCredentialsFile(path string) error {
+	cred, err := LoadCredentialsFile(path)
+	if err != nil {
+		return err
+	}
+	l.SetCredentials(cred)
+	return nil
+}
+
+// ftpHost returns FtpHost if set, otherwise the Host without any Restconf port
+func (l *LumiaOlt) ftpHost() string {
+	if l.FtpHost != "" {
+		return l.FtpHost
+	}
+	if h, _, err := net.SplitHostPort(l.Host); err == nil {
+		return h
+	}
+	return l.Host
+}
+
 // HostIsReachable is a helper method to ensure HTTPS access on port 443 to specified Host address
 func (l *LumiaOlt) HostIsReachable() bool {
 	err := CheckHost(l.Host, 1)
@@ -59,45 +141,30 @@ func (l *LumiaOlt) HostIsReachable() bool {
 func (l *LumiaOlt) CacheSwap() {
 	// logprinting as a placeholder for some form of logging of past caches/debug visibility
 	//log.Println(l.Cache)
+	l.treeMu.Lock()
+	defer l.treeMu.Unlock()
 	l.Cache = l.Current
 }
 
 func (l *LumiaOlt) CacheBack() {
 	// reversed from cache if error in call
+	l.treeMu.Lock()
+	defer l.treeMu.Unlock()
 	l.Current = l.Cache
 }
 
-/*
-func (l *LumiaOlt) CacheDiff() []interface{} {
-	// compare l.Current and l.Cache
-	return // any items in one but not the other
-}
-
-// GetIskratelMsan returns a populated struct of all active profiles on the OLT
-func (l *LumiaOlt) GetIskratelMsan() *IskratelMsan {
-	p := GetAllProfiles()
-	l.CacheSwap()
-	l.Current = p
-	return p
-}
-
-// GetAllProfiles is a helper/merger function that combines all individual IskratelMsan GET logic
-func (l *LumiaOlt) GetAllProfiles() (*IskratelMsan, error) {
-	sp := NewIskratelMsan()
-}
-*/
-
 // GetCurrentLogs accepts a path as output directory location
 func (l *LumiaOlt) GetCurrentLogs(path string) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
-	info, _ := os.Stat(absPath)
-	if !info.IsDir() {
+	// a path that does not exist yet is created as the output directory
+	info, err := os.Stat(absPath)
+	if err == nil && !info.IsDir() {
 		absPath = filepath.Dir(absPath)
 	}
-	cl, err := NewFtpClient(l.Host, auth)
+	cl, err := NewFtpClient(l.ftpHost(), l.Credentials.Cookie())
 	if err != nil {
 		return err
 	}
@@ -119,7 +186,7 @@ func (l *LumiaOlt) GetCurrentLogsTail(path string) error {
 	if !info.IsDir() {
 		absPath = filepath.Dir(absPath)
 	}
-	cl, err := NewFtpClient(l.Host, auth)
+	cl, err := NewFtpClient(l.ftpHost(), l.Credentials.Cookie())
 	if err != nil {
 		return err
 	}
@@ -149,7 +216,8 @@ func (l *LumiaOlt) UploadConfig(path string) error {
 	if err != nil {
 		return err
 	}
-	cl, err := NewFtpClient(l.Host, auth)
+	defer file.Close()
+	cl, err := NewFtpClient(l.ftpHost(), l.Credentials.Cookie())
 	if err != nil {
 		return err
 	}
@@ -176,7 +244,7 @@ func (l *LumiaOlt) DeleteConfig(path string) error {
 	// ensure the file does not contain other path info harmful to the operation
 	path = filepath.Base(path)
 
-	cl, err := NewFtpClient(l.Host, auth)
+	cl, err := NewFtpClient(l.ftpHost(), l.Credentials.Cookie())
 	if err != nil {
 		return err
 	}
@@ -190,26 +258,32 @@ func (l *LumiaOlt) DeleteConfig(path string) error {
 }
 
 func (l *LumiaOlt) GetOnuBlacklist() (*OnuBlacklistList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuBlacklist)
+	return l.GetOnuBlacklistCtx(context.Background())
+}
+
+// GetOnuBlacklistCtx is GetOnuBlacklist with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuBlacklistCtx(ctx context.Context) (*OnuBlacklistList, error) {
+	rawJson, err := l.Client.Get(ctx, onuBlacklist)
 	if err != nil {
 		return nil, err
 	}
 	//fmt.Println(string(rawJson))
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	im, err := l.updateCurrent(onuBlacklist, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return nil, err
 	}
 	var list OnuBlacklistList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuBlackListTable.MsanOnuBlackListEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuBlackListTable.MsanOnuBlackListEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuBlackListTable.MsanOnuBlackListEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuBlackListTable.MsanOnuBlackListEntry[i])
 	}
 	return &list, nil
 }
 
 // LoadOnuAuthList opens the supplied filepath and reads line-separated entries to build a slice of registered serial numbers
-// each line can include comma-, space- or tab-separated lists that include up to 6 service profiles to apply to the ONU registration
+// each line can include comma-, space- or tab-separated lists that include up to 6 service profiles to apply to the ONU registration.
+// An optional pw=<password> column sets the password the ONU must present; a line starting with it instead of a serial number
+// authorizes any ONU presenting that password (a registration ID typed in by the installer).
+// A serial number or password already on the list has its services and password updated
 func (l *LumiaOlt) LoadOnuAuthList(path string) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -219,6 +293,8 @@ func (l *LumiaOlt) LoadOnuAuthList(path string) error {
 	if err != nil {
 		return err
 	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
 	s := bufio.NewScanner(authFile)
 	for s.Scan() {
 		line := strings.Fields(s.Text())
@@ -228,8 +304,23 @@ func (l *LumiaOlt) LoadOnuAuthList(path string) error {
 		if len(line) > 7 {
 			line = line[0:6]
 		}
-		sn := strings.TrimSpace(line[0])
-		if len(sn) != 12 {
+		var sn, pw string
+		var services []string
+		for i, f := range line {
+			switch {
+			case strings.HasPrefix(f, "pw="):
+				pw = strings.TrimPrefix(f, "pw=")
+			case i == 0:
+				sn = strings.TrimSpace(f)
+			default:
+				services = append(services, f)
+			}
+		}
+		if sn == "" && pw == "" {
+			fmt.Printf("%v: %s\n", ErrNotInput, line)
+			continue
+		}
+		if sn != "" && len(sn) != 12 {
 			if len(sn) == 8 {
 				sn = "ISKT" + sn
 			} else {
@@ -237,39 +328,48 @@ func (l *LumiaOlt) LoadOnuAuthList(path string) error {
 				continue
 			}
 		}
-		if l.ValidateSn(sn) {
-			if len(line) < 2 {
-				fmt.Printf("%v: %s\n", ErrExists, sn)
+		if onuReg, err := l.onuRegisterFor(sn, pw); err == nil {
+			if len(services) < 1 && pw == "" {
+				fmt.Printf("%v: %s\n", ErrExists, registerKey(sn, pw))
 				continue
-			} // should be able to access that object and update its service profiles
-			// but this can wait until after the proper helper methods are created for the single
+			}
+			// update the existing entry rather than listing the Onu twice
+			if len(services) > 0 {
+				onuReg.Services = services
+			}
+			if pw != "" {
+				onuReg.Password = pw
+			}
+			continue
 		}
 		onuReg := &OnuRegister{
 			SerialNumber: sn,
-		}
-		// removing the AddSnToAuthList function
-		if len(line) > 2 {
-			for i := 1; i < len(line); i++ {
-				onuReg.Services = append(onuReg.Services, line[i])
-			}
+			Password:     pw,
+			Services:     services,
+			State:        StatePreProvisioned,
 		}
 		//fmt.Println(onuReg)
-		l.Registration = append(l.Registration, onuReg)
+		l.addRegister(onuReg)
 	}
 	//fmt.Printf("Onu Registry now has %d entries\n", len(l.Registration))
-	return nil
+	return l.saveRegistry()
 }
 
 func (l *LumiaOlt) ListEssentialRegistryData(onuReg *OnuRegister) map[string]interface{} {
 	var OnuRegistryData = map[string]interface{}{
 		OnuRegisterHeaders[0]: onuReg.Interface,
 		OnuRegisterHeaders[1]: onuReg.SerialNumber,
-		OnuRegisterHeaders[2]: onuReg.ConcatServices(),
+		OnuRegisterHeaders[2]: onuReg.Password,
+		OnuRegisterHeaders[3]: onuReg.ConcatServices(),
+		OnuRegisterHeaders[4]: onuReg.State,
+		OnuRegisterHeaders[5]: onuReg.SubscriberID,
 	}
 	return OnuRegistryData
 }
 
 func (l *LumiaOlt) TabwriteRegistry() {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
 	// initiate a tabwriter
 	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
 	// write tab-separated header values
@@ -303,28 +403,90 @@ func (l *LumiaOlt) TabwriteRegistry() {
 // GetOnuRegisterBySn looks through the OLT's Registration list by Serial Number and
 // returns the OnuRegister object of the matching serial number, or an error
 func (l *LumiaOlt) GetOnuRegisterBySn(sn string) (*OnuRegister, error) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.onuRegisterBySn(sn)
+}
+
+// onuRegisterBySn is GetOnuRegisterBySn for callers already holding l.regMu
+func (l *LumiaOlt) onuRegisterBySn(sn string) (*OnuRegister, error) {
 	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].SerialNumber == sn {
+		if l.Registration[i].SerialNumber == sn && l.Registration[i].State != StateRemoved {
 			return l.Registration[i], nil
 		}
 	}
 	return nil, ErrNotExists
 }
 
-// GetOnuRegisterByIntf looks through the OLT's Registration list by Interface (0/x/y) and
-// returns the OnuRegister object of the matching interface, or an error
-func (l *LumiaOlt) GetOnuRegisterByIntf(intf string) (*OnuRegister, error) {
+// onuRegisterByIntf finds the OnuRegister registered on the Onu interface (0/x/y). The caller holds l.regMu
+func (l *LumiaOlt) onuRegisterByIntf(intf string) (*OnuRegister, error) {
 	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].Interface == intf {
+		if l.Registration[i].Interface == intf && l.Registration[i].State != StateRemoved {
 			return l.Registration[i], nil
 		}
 	}
 	return nil, ErrNotExists
 }
 
+// GetOnuRegisterByPassword looks through the OLT's Registration list for an Onu authorized by password only and
+// returns the OnuRegister object of the matching password, or an error
+func (l *LumiaOlt) GetOnuRegisterByPassword(password string) (*OnuRegister, error) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.onuRegisterFor("", password)
+}
+
+// onuRegisterFor finds the OnuRegister by Serial Number, or by password for an Onu authorized by password only.
+// The caller holds l.regMu
+func (l *LumiaOlt) onuRegisterFor(sn, password string) (*OnuRegister, error) {
+	if sn != "" {
+		return l.onuRegisterBySn(sn)
+	}
+	if password == "" {
+		return nil, ErrNotExists
+	}
+	for i := 0; i < len(l.Registration); i++ {
+		if l.Registration[i].SerialNumber == "" && l.Registration[i].Password == password && l.Registration[i].State != StateRemoved {
+			return l.Registration[i], nil
+		}
+	}
+	return nil, ErrNotExists
+}
+
+// MatchBlacklistEntry finds the OnuRegister for an Onu on the Blacklist: by Serial Number, or by the password it presents
+// for an Onu authorized by password only. ErrNotAuthorized is returned if there is none. If the Onu presents another password
+// than the one listed for its Serial Number, or the OLT reports a Password Mismatch, the OnuRegister is returned with
+// ErrPasswordMismatch, as authorizing it would only return it to the Blacklist
+func (l *LumiaOlt) MatchBlacklistEntry(bl *OnuBlacklist) (*OnuRegister, error) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg, err := l.onuRegisterBySn(bl.SerialNumber)
+	if err != nil {
+		onuReg, err = l.onuRegisterFor("", bl.Password)
+		if err != nil {
+			return nil, ErrNotAuthorized
+		}
+		return onuReg, nil
+	}
+	if bl.Cause == BlCausePasswordMismatch || (onuReg.Password != "" && onuReg.Password != bl.Password) {
+		return onuReg, fmt.Errorf("%w: %s", ErrPasswordMismatch, bl.SerialNumber)
+	}
+	return onuReg, nil
+}
+
+// GetOnuRegisterByIntf looks through the OLT's Registration list by Interface (0/x/y) and
+// returns the OnuRegister object of the matching interface, or an error
+func (l *LumiaOlt) GetOnuRegisterByIntf(intf string) (*OnuRegister, error) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.onuRegisterByIntf(intf)
+}
+
 // GetOnuRegistryProfileUsage looks through the OLT's Registration list by Service Profile names and
 // returns a list of the Serial Numbers using the requested service profile, if any
 func (l *LumiaOlt) GetOnuRegistryProfileUsage(sp string) []string {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
 	var sl []string
 	for i := 0; i < len(l.Registration); i++ {
 		for _, p := range l.Registration[i].Services {
@@ -338,88 +500,119 @@ func (l *LumiaOlt) GetOnuRegistryProfileUsage(sp string) []string {
 
 // AddSnToAuthList adds a single Serial Number to the olt's AuthorizeOnuSn list.
 func (l *LumiaOlt) AddSnToAuthList(sn string) error {
-	if l.ValidateSn(sn) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	if l.validateSn(sn) {
 		return ErrExists
 	}
 	onuReg := &OnuRegister{
 		SerialNumber: sn,
+		State:        StatePreProvisioned,
 	}
-	l.Registration = append(l.Registration, onuReg)
-	return nil
+	l.addRegister(onuReg)
+	return l.saveRegistry()
 }
 
 // UpdateOnuRegistry updates the Olt's record of the Onu Serial Numbers currently active in the system.
-// This list may differ from the AuthorizedOnuList if devices are pre-authorized but not yet deployed.
+// This list may differ from the AuthorizedOnuList if devices are pre-authorized but not yet deployed,
+// which are kept as pre-provisioned. The state of each entry is reconciled with the Olt, see reconcileRegistry,
+// an Onu disabled by its AdminState being suspended until it is enabled again, and the RegistryStore is saved when one is open.
 // Replaces UpdateRegisteredOnuList
 func (l *LumiaOlt) UpdateOnuRegistry() error {
-	rawJson, err := RestGetProfiles(l.Host, onuConfig)
+	return l.UpdateOnuRegistryCtx(context.Background())
+}
+
+// UpdateOnuRegistryCtx is UpdateOnuRegistry with its requests bounded by ctx
+func (l *LumiaOlt) UpdateOnuRegistryCtx(ctx context.Context) error {
+	rawJson, err := l.Client.Get(ctx, onuConfig)
 	if err != nil {
 		return err
 	}
 	//fmt.Println(string(rawJson))
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	cfgs, err := l.updateCurrent(onuConfig, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return err
 	}
 	// [NP0223] Intf is key, Sn is value
 	reg := make(map[string]string)
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry); i++ {
-		reg[l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry[i].IfName] = l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry[i].SerialNumber
+	// Intf is key, Password is value
+	pwReg := make(map[string]string)
+	// Intf of the Onu disabled by their AdminState
+	disabled := make(map[string]bool)
+	for i := 0; i < len(cfgs.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry); i++ {
+		cfg := cfgs.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry[i]
+		if cfg.SerialNumber == "" && cfg.Password == "" {
+			// a blank config leaves the interface unused
+			continue
+		}
+		reg[cfg.IfName] = cfg.SerialNumber
+		pwReg[cfg.IfName] = cfg.Password
+		disabled[cfg.IfName] = cfg.AdminState == OnuAdminDown
 	}
-	rawJson, err = RestGetProfiles(l.Host, onuProfiles)
+	rawJson, err = l.Client.Get(ctx, onuProfiles)
 	if err != nil {
 		return err
 	}
 	//fmt.Println(string(rawJson))
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	ops, err := l.updateCurrent(onuProfiles, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return err
 	}
 	// [NP0223] Intf is key, Profiles are value
 	preg := make(map[string][]string)
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry); i++ {
-		preg[l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].IfName] = append(preg[l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].IfName], l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].ServiceProfileName)
+	for i := 0; i < len(ops.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry); i++ {
+		preg[ops.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].IfName] = append(preg[ops.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].IfName], ops.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i].ServiceProfileName)
 	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	configured := make(map[*OnuRegister]bool)
 	for k, v := range reg {
-		if !l.ValidateSn(v) {
-			onu := &OnuRegister{
+		// the interface is in use on the OLT now, so no longer needs holding
+		delete(l.reserved, k)
+		onu, err := l.onuRegisterFor(v, pwReg[k])
+		if err != nil {
+			onu = l.addRegister(&OnuRegister{
 				SerialNumber: v,
+				Password:     pwReg[k],
 				Interface:    k,
 				Services:     preg[k],
-			}
-			l.Registration = append(l.Registration, onu)
+			})
 		} else {
 			// the Serial Number already exists but is not necessarily up to date
-			onu, err := l.GetOnuRegisterBySn(v)
-			if err != nil {
-				fmt.Println(err)
-				continue
-			}
 			onu.Interface = k
 			onu.Services = preg[k]
-		}
-	}
-	// remove any incomplete entries
-	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].Interface == "" {
-			err = l.RemoveOnuAuthEntry(l.Registration[i].SerialNumber)
-			if err != nil {
-				return err
+			if pwReg[k] != "" {
+				onu.Password = pwReg[k]
 			}
 		}
+		switch {
+		case disabled[k] && onu.State != StateSuspended:
+			// disabled without the record of SuspendOnu, the bindings left in place are the ones to restore
+			onu.State = StateSuspended
+			onu.SuspendedServices = append([]string(nil), preg[k]...)
+			onu.WalledGarden = ""
+		case !disabled[k] && onu.State == StateSuspended && onu.WalledGarden == "":
+			// enabled again outside goPon, which ends a suspension by AdminState
+			onu.State = StateActive
+			onu.SuspendedServices = nil
+		}
+		configured[onu] = true
 	}
-
-	return nil
+	l.reconcileRegistry(configured)
+	return l.saveRegistry()
 }
 
 // ValidateSn loops over the list of Registered Onu, looking at Serial Numbers to see if the supplied value already exists
 func (l *LumiaOlt) ValidateSn(sn string) bool {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.validateSn(sn)
+}
+
+// validateSn is ValidateSn for callers already holding l.regMu
+func (l *LumiaOlt) validateSn(sn string) bool {
 	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].SerialNumber == sn {
+		if l.Registration[i].SerialNumber == sn && l.Registration[i].State != StateRemoved {
 			return true
 		}
 	}
@@ -427,89 +620,73 @@ func (l *LumiaOlt) ValidateSn(sn string) bool {
 }
 
 // NextAvailableOnuInterface receives an Olt Interface (0/x) and checks the Registration index to find the next available Onu Subinterface(0/x/y)
-// returns nil if there are no available interfaces left (1-128); otherwise returns the given Olt interface with an Onu Subinterface attached
+// returns the given Olt interface with an Onu Subinterface attached. The interface is held until UpdateOnuRegistry finds it configured
+// or it is returned with ReleaseOnuInterface, so concurrent callers are never handed the same one
 func (l *LumiaOlt) NextAvailableOnuInterface(intf string) string {
-	// Stateful Issue: called in sequence supplies the same next value
-	// need to update list with given out interface otherwise!
-	list := l.GenerateUsedSubinterfaceList(intf)
-	// need to add the given out interface to the known list as a temporary measure until it can be updated
-	if len(list) < 1 {
-		//fmt.Println("No used ONU on this interface")
-		newIntf := fmt.Sprintf("%s/%d", intf, 1)
-		return newIntf
-	}
-	sort.Ints(list)
-	var counter int
-
-	for {
-		// counter is incremented each time that subinterface is used
-		// until a match is found that is not currently in use, and returned
-		counter++
-		if counter > 127 {
-			// design choice: return an invalid interface or not?
-			// if all 128 are in use, this function needs to return 'false' as in none available
-			// instead of letting this logic control that, don't check if 128 is available but try to post anyway
-			// act on the "409 Conflict" returned rather than internal logic, easier to diagnose
-			// otherwise, the handler of this function needs to check for an error and not post
-			// and in doing so, return a descriptive error with the same effect as 409
-			// when in doubt, let REST sort it out
-			break
-		}
-		// accounting for gaps in linear sequence
-		if counter != list[counter-1] {
-			break
-		}
-		// no linear gaps but length of list is exceeded
-		if counter == len(list) {
-			counter += 1
-			break
-		}
-	}
-
-	newIntf := fmt.Sprintf("%s/%d", intf, counter)
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	newIntf := l.nextSubinterface(intf)
+	l.reserved[newIntf] = true
 	return newIntf
 }
 
+// ReleaseOnuInterface returns an interface handed out by NextAvailableOnuInterface that was not configured
+func (l *LumiaOlt) ReleaseOnuInterface(intf string) {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	delete(l.reserved, intf)
+}
+
 // NextAvailableOnuInterfaceUpdateRegister receives an Olt Interface (0/x) and an OnuRegister object
 // and checks the Registration index to find the next available Onu Subinterface(0/x/y)
-// returns the OnuRegister object with the Onu Subinterface updated, if one is available
+// returns the OnuRegister object with the Onu Subinterface updated. When the OnuRegister is part of the Registration index
+// the interface counts as used from then on, so concurrent callers are never handed the same one
 func (l *LumiaOlt) NextAvailableOnuInterfaceUpdateRegister(intf string, onuReg *OnuRegister) *OnuRegister {
-	list := l.GenerateUsedSubinterfaceList(intf)
-	if len(list) < 1 {
-		//fmt.Println("No used ONU on this interface")
-		newIntf := fmt.Sprintf("%s/%d", intf, 1)
-		onuReg.Interface = newIntf
-		return onuReg
-	}
-	sort.Ints(list)
-	var counter int
-	// see notes in NextAvailableOnuInterface
-	for {
-		counter++
-		if counter > 127 {
-			break
-		}
-		if counter != list[counter-1] {
-			break
-		}
-		if counter == len(list) {
-			counter += 1
-			break
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg.Interface = l.nextSubinterface(intf)
+	return onuReg
+}
+
+// nextSubinterface returns the lowest Onu Subinterface of the Olt interface that is neither registered nor held.
+// If 1-127 are all in use 128 is returned without checking it:
+// act on the "409 Conflict" returned rather than internal logic, easier to diagnose. When in doubt, let REST sort it out.
+// The caller holds l.regMu
+func (l *LumiaOlt) nextSubinterface(intf string) string {
+	used := make(map[int]bool)
+	for _, n := range l.usedSubinterfaces(intf) {
+		used[n] = true
+	}
+	for r := range l.reserved {
+		if strings.HasPrefix(r, intf+"/") {
+			n, _ := strconv.Atoi(r[len(intf)+1:])
+			used[n] = true
 		}
 	}
-
-	newIntf := fmt.Sprintf("%s/%d", intf, counter)
-	onuReg.Interface = newIntf
-	return onuReg
+	counter := 1
+	for counter < 128 && used[counter] {
+		counter++
+	}
+	return fmt.Sprintf("%s/%d", intf, counter)
 }
 
 // GenerateUsedSubinterfaceList filters the Registration index to provide a slice of the subinterface values used on a specific OLT port.
 // For example if 0/2 is supplied and 0/2/1, 0/2/3, 0/2/4 are in use the returned value will be []int{1,3,4}
 func (l *LumiaOlt) GenerateUsedSubinterfaceList(intf string) []int {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.usedSubinterfaces(intf)
+}
+
+// usedSubinterfaces is GenerateUsedSubinterfaceList for callers already holding l.regMu
+func (l *LumiaOlt) usedSubinterfaces(intf string) []int {
 	var list []int
 	var entry int
 	for i := 0; i < len(l.Registration); i++ {
-		if strings.HasPrefix(l.Registration[i].Interface, intf) {
+		if l.Registration[i].State == StateRemoved {
+			continue
+		}
+		if strings.HasPrefix(l.Registration[i].Interface, fmt.Sprintf("%s/", intf)) { // trailing '/' so 0/1 does not match 0/10 and above
 			add := strings.Split(l.Registration[i].Interface, "/")
 			// this is a controlled list, can assume the length will be 3
 			// the third segment represents the in-use sub-interface on the filtered olt port
@@ -525,6 +702,8 @@ func (l *LumiaOlt) GenerateUsedSubinterfaceList(intf string) []int {
 // GeneratePerPortOnuRegistrationList filters the register Onu map by Port Prefix to generate a filtered map
 // where the key is the subinterface and the value is the serial number of the registered device
 func (l *LumiaOlt) GeneratePerPortOnuRegistrationList(intf string) map[int]string {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
 	list := make(map[int]string)
 	for i := 0; i < len(l.Registration); i++ {
 		if strings.HasPrefix(l.Registration[i].Interface, fmt.Sprintf("%s/", intf)) { // added trailing '/' so 0/1 does not evalute true for 10 and above
@@ -537,43 +716,63 @@ func (l *LumiaOlt) GeneratePerPortOnuRegistrationList(intf string) map[int]strin
 	return list
 }
 
-// AuthorizeOnu accepts a single OnuConfig object and attempts to register the device
+// AuthorizeOnu accepts a single OnuConfig object and attempts to register the device.
+// The Onu must be in the Registration index by Serial Number, or by password when the OnuConfig has no Serial Number.
+// If a password is listed for the Onu the OnuConfig must carry the same one: ErrPasswordMismatch is returned
+// instead of configuring an Onu that would stay on the Blacklist with a Password Mismatch
 func (l *LumiaOlt) AuthorizeOnu(ocfg *OnuConfig) error {
-	if l.ValidateSn(ocfg.SerialNumber) {
-		ifName, jsonData := ocfg.GenerateJson()
-		if ifName == "" {
-			return ErrNotStruct
-		}
-		//fmt.Println(jsonData)
-		resp, err := RestPatchProfile(l.Host, onuConfig, UrlEncodeInterface(ifName), jsonData)
-		if err != nil {
-			return err
-		}
-		if resp != responseOk {
-			fmt.Println(resp)
-			return ErrNotStatusOk
-		}
-		return nil
-	} else {
+	return l.AuthorizeOnuCtx(context.Background(), ocfg)
+}
+
+// AuthorizeOnuCtx is AuthorizeOnu with its requests bounded by ctx
+func (l *LumiaOlt) AuthorizeOnuCtx(ctx context.Context, ocfg *OnuConfig) error {
+	l.regMu.Lock()
+	onuReg, err := l.onuRegisterFor(ocfg.SerialNumber, ocfg.Password)
+	var password string
+	if err == nil {
+		password = onuReg.Password
+	}
+	l.regMu.Unlock()
+	if err != nil {
 		return ErrNotAuthorized
 	}
+	if password != "" && password != ocfg.Password {
+		return fmt.Errorf("%w: %s", ErrPasswordMismatch, ocfg.SerialNumber)
+	}
+	ifName, jsonData := ocfg.GenerateJson()
+	if ifName == "" {
+		return ErrNotStruct
+	}
+	//fmt.Println(jsonData)
+	err = l.Client.Patch(ctx, onuConfig, UrlEncodeInterface(ifName), jsonData)
+	if err != nil {
+		return err
+	}
+	if l.Graph != nil {
+		l.Graph.put(onuConfig, ifName, nil)
+	}
+	return nil
 }
 
 // AuthorizeOnuOverride accepts a single OnuConfig object and forcefully registers the device
 func (l *LumiaOlt) AuthorizeOnuOverride(ocfg *OnuConfig) error {
+	return l.AuthorizeOnuOverrideCtx(context.Background(), ocfg)
+}
+
+// AuthorizeOnuOverrideCtx is AuthorizeOnuOverride with its requests bounded by ctx
+func (l *LumiaOlt) AuthorizeOnuOverrideCtx(ctx context.Context, ocfg *OnuConfig) error {
 	// do not validate SN first
 	ifName, jsonData := ocfg.GenerateJson()
 	if ifName == "" {
 		return ErrNotStruct
 	}
 	//fmt.Println(jsonData)
-	resp, err := RestPatchProfile(l.Host, onuConfig, UrlEncodeInterface(ifName), jsonData)
+	err := l.Client.Patch(ctx, onuConfig, UrlEncodeInterface(ifName), jsonData)
 	if err != nil {
 		return err
 	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
+	if l.Graph != nil {
+		l.Graph.put(onuConfig, ifName, nil)
 	}
 	//fmt.Printf("Forceably registered SN: %s\n", ocfg.SerialNumber)
 	return nil
@@ -581,53 +780,132 @@ func (l *LumiaOlt) AuthorizeOnuOverride(ocfg *OnuConfig) error {
 
 // DeauthOnuBySn accepts a Serial Number string as input and attempts to Deauthorize it
 func (l *LumiaOlt) DeauthOnuBySn(serNo string) error {
-	var err error
+	return l.DeauthOnuBySnCtx(context.Background(), serNo)
+}
+
+// DeauthOnuBySnCtx is DeauthOnuBySn with its requests bounded by ctx
+func (l *LumiaOlt) DeauthOnuBySnCtx(ctx context.Context, serNo string) error {
+	// an Onu authorized by password only has no Serial Number to find it by
+	if serNo == "" {
+		return ErrNotInput
+	}
 	// assume the registered Onu List is up to date
-	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].SerialNumber == serNo {
-			// clear all service profiles from olt first
-			// so they are not left over for the next device who takes this intf
-			for n := 0; n < len(l.Registration[i].Services); n++ {
-				err = l.RemoveOnuProfileUsage(l.Registration[i].Interface, l.Registration[i].Services[n])
-				if err != nil {
-					fmt.Println(err)
-					// choosing to not error handle here, but provide as info
-				}
-			}
-			ocfg := GenerateBlankConfig(l.Registration[i].Interface)
-			intf, jsonData := ocfg.GenerateJson()
-			resp, err := RestPatchProfile(l.Host, onuConfig, UrlEncodeInterface(intf), jsonData)
-			if err != nil {
-				return err
-			}
-			if resp != responseOk {
-				fmt.Println(resp)
-				return ErrNotStatusOk
-			}
-			// remove from l.AuthorizeOnu
-			return l.RemoveOnuAuthEntry(serNo)
+	l.regMu.Lock()
+	onuReg, err := l.onuRegisterBySn(serNo)
+	if err != nil {
+		l.regMu.Unlock()
+		return err
+	}
+	// the requests are made without holding the lock, from a copy of the entry
+	intf := onuReg.Interface
+	services := append([]string(nil), onuReg.Services...)
+	l.regMu.Unlock()
+	return l.deauth(ctx, serNo, intf, services)
+}
+
+// DeauthOnuByIntf accepts an Onu interface (0/x/y) as input and attempts to Deauthorize the Onu registered on it,
+// including an Onu authorized by password only
+func (l *LumiaOlt) DeauthOnuByIntf(intf string) error {
+	return l.DeauthOnuByIntfCtx(context.Background(), intf)
+}
+
+// DeauthOnuByIntfCtx is DeauthOnuByIntf with its requests bounded by ctx
+func (l *LumiaOlt) DeauthOnuByIntfCtx(ctx context.Context, intf string) error {
+	if intf == "" {
+		return ErrNotInput
+	}
+	l.regMu.Lock()
+	onuReg, err := l.onuRegisterByIntf(intf)
+	if err != nil {
+		l.regMu.Unlock()
+		return err
+	}
+	key := registerKey(onuReg.SerialNumber, onuReg.Password)
+	services := append([]string(nil), onuReg.Services...)
+	l.regMu.Unlock()
+	return l.deauth(ctx, key, intf, services)
+}
+
+// deauth clears the services and configuration of the interface, then removes the entry of the registerKey
+// from the Registration index
+func (l *LumiaOlt) deauth(ctx context.Context, key, intf string, services []string) error {
+	var err error
+	// clear all service profiles from olt first
+	// so they are not left over for the next device who takes this intf
+	for _, sp := range services {
+		err = l.RemoveOnuProfileUsageCtx(ctx, intf, sp)
+		if err != nil {
+			fmt.Println(err)
+			// choosing to not error handle here, but provide as info
 		}
 	}
-	return ErrNotExists
+	ocfg := GenerateBlankConfig(intf)
+	intf, jsonData := ocfg.GenerateJson()
+	err = l.Client.Patch(ctx, onuConfig, UrlEncodeInterface(intf), jsonData)
+	if err != nil {
+		return err
+	}
+	if l.Graph != nil {
+		l.Graph.Remove(onuConfig, intf)
+	}
+	// remove from l.AuthorizeOnu
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	err = l.removeOnuAuthEntry(key)
+	if err != nil {
+		return err
+	}
+	return l.saveRegistry()
 }
 
-// RemoveOnuAuthEntry accepts an ONU Serial Number as input and removes the entry from the Registration index
+// RemoveOnuAuthEntry accepts an ONU Serial Number as input and removes the entry from the Registration index.
+// With a RegistryStore open the entry is kept as removed, so its details stay on record
 func (l *LumiaOlt) RemoveOnuAuthEntry(serNo string) error {
+	if serNo == "" {
+		return ErrNotInput
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	err := l.removeOnuAuthEntry(serNo)
+	if err != nil {
+		return err
+	}
+	return l.saveRegistry()
+}
+
+// removeOnuAuthEntry removes the entry of a registerKey, the Serial Number of an Onu that has one, from the Registration index.
+// The caller holds l.regMu
+func (l *LumiaOlt) removeOnuAuthEntry(key string) error {
 	for i := 0; i < len(l.Registration); i++ {
-		if l.Registration[i].SerialNumber == serNo {
-			// swap method replaces the found entry with the 0-index entry
-			l.Registration[i] = l.Registration[0]
-			// then shortens the list by removing the duplicated first entry
-			l.Registration = l.Registration[1:]
+		if registerKey(l.Registration[i].SerialNumber, l.Registration[i].Password) == key && l.Registration[i].State != StateRemoved {
+			if l.Store != nil {
+				l.Registration[i].retire()
+			} else {
+				l.removeOnuRegister(i)
+			}
 			return nil
 		}
 	}
 	return ErrNotExists
 }
 
-// DeauthOnuBySnList is a wrapper that extracts Serial Numbers from a file and attempts to Deauth each.
-// Entries in the file are expected to follow the Auth format, with one SN per line occuring as the first entry
+// removeOnuRegister removes the entry at index i of the Registration index. The caller holds l.regMu
+func (l *LumiaOlt) removeOnuRegister(i int) {
+	// swap method replaces the found entry with the 0-index entry
+	l.Registration[i] = l.Registration[0]
+	// then shortens the list by removing the duplicated first entry
+	l.Registration = l.Registration[1:]
+}
+
+// DeauthOnuBySnList is a wrapper that extracts Serial Numbers from a file and attempts to Deauth each, l.Concurrency at a time.
+// Entries in the file are expected to follow the Auth format, with one SN per line occuring as the first entry.
+// Failures do not stop the other Onu and are returned together as a *BulkError
 func (l *LumiaOlt) DeauthOnuBySnList(path string) error {
+	return l.DeauthOnuBySnListCtx(context.Background(), path)
+}
+
+// DeauthOnuBySnListCtx is DeauthOnuBySnList with its requests bounded by ctx
+func (l *LumiaOlt) DeauthOnuBySnListCtx(ctx context.Context, path string) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return err
@@ -654,54 +932,60 @@ func (l *LumiaOlt) DeauthOnuBySnList(path string) error {
 	if len(dereg) < 1 {
 		return ErrNotInput
 	}
-	var success int
-	for _, sn := range dereg {
-		err = l.DeauthOnuBySn(sn)
-		if err != nil {
-			fmt.Println(err)
-			continue
-		} else {
-			//fmt.Printf("Onu SN [%s] Deauthorized\n", sn)
-			success++
-		}
+	err = l.runBulk(ctx, dereg, func(_ int, sn string) error {
+		return l.DeauthOnuBySnCtx(ctx, sn)
+	})
+	failed := 0
+	if bulkErr, ok := err.(*BulkError); ok {
+		failed = len(bulkErr.Entry)
 	}
-	fmt.Printf("%d/%d Onu Deauthorized by SerialNumber\n", success, len(dereg))
-	return nil
+	fmt.Printf("%d/%d Onu Deauthorized by SerialNumber\n", len(dereg)-failed, len(dereg))
+	return err
 }
 
 // GetOnuProfileUsage performs a Get request to the OLT to return the
 func (l *LumiaOlt) GetOnuProfileUsage() (*OnuProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuProfiles)
+	return l.GetOnuProfileUsageCtx(context.Background())
+}
+
+// GetOnuProfileUsageCtx is GetOnuProfileUsage with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuProfileUsageCtx(ctx context.Context) (*OnuProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, onuProfiles)
 	if err != nil {
 		return nil, err
 	}
 	//fmt.Println(string(rawJson))
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	im, err := l.updateCurrent(onuProfiles, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return nil, err
 	}
 	var list OnuProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry[i])
 	}
 	return &list, nil
 }
 
 func (l *LumiaOlt) PostOnuProfile(op *OnuProfile) error {
+	return l.PostOnuProfileCtx(context.Background(), op)
+}
+
+// PostOnuProfileCtx is PostOnuProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostOnuProfileCtx(ctx context.Context, op *OnuProfile) error {
 	ifName, jsonData := op.GenerateJson()
 	if ifName == "" {
 		return ErrNotStruct
 	}
 	//fmt.Println(jsonData)
-	resp, err := RestPostProfile(l.Host, onuProfiles, UrlEncodeInterface(ifName), jsonData)
+	err := l.Client.Post(ctx, onuProfiles, UrlEncodeInterface(ifName), jsonData)
 	if err != nil {
 		return err
 	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
+	if l.Graph != nil {
+		l.Graph.put(onuProfiles, ifName+","+op.ServiceProfileName, map[string]ProfileNode{
+			"ServiceProfileName": {serviceProfiles, op.ServiceProfileName},
+			"IfName":             {onuConfig, ifName},
+		})
 	}
 	return nil
 }
@@ -710,14 +994,18 @@ func (l *LumiaOlt) PostOnuProfile(op *OnuProfile) error {
 // This operation does not deregister the ONU, and any other service profiles will remain in effect.
 // This is a good example of how multiple fields can be combined together in the URL query with commas ','
 func (l *LumiaOlt) RemoveOnuProfileUsage(intf, spName string) error {
+	return l.RemoveOnuProfileUsageCtx(context.Background(), intf, spName)
+}
+
+// RemoveOnuProfileUsageCtx is RemoveOnuProfileUsage with its requests bounded by ctx
+func (l *LumiaOlt) RemoveOnuProfileUsageCtx(ctx context.Context, intf, spName string) error {
 	removalQuery := UrlEncodeInterface(intf) + "," + spName
-	resp, err := RestDeleteProfile(l.Host, onuProfiles, removalQuery)
+	err := l.Client.Delete(ctx, onuProfiles, removalQuery)
 	if err != nil {
 		return err
 	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
+	if l.Graph != nil {
+		l.Graph.Remove(onuProfiles, intf+","+spName)
 	}
 	return nil
 }
@@ -731,52 +1019,84 @@ func (l *LumiaOlt) AddServiceToOnu(onuReg *OnuRegister, sp string) error {
 }
 
 // AddMultipleServicesToOnu is a wrapper around AddServiceToOnu allowing multiple profiles to be supplied at once.
-// This method does not reduce the number of Http interactions. And returns at the point where any profile fails to apply
+// This method does not reduce the number of Http interactions. If any profile fails to apply, the profiles already
+// added by this call are removed again so the Onu is left with the services it had before
 func (l *LumiaOlt) AddMultipleServicesToOnu(onuReg *OnuRegister, spList []string) error {
+	var undo []func() error
 	for _, sp := range spList {
 		err := l.AddServiceToOnu(onuReg, sp)
 		if err != nil {
-			return err
+			return l.rollback(fmt.Errorf("%s: %w", sp, err), undo)
 		}
+		spName := sp
+		undo = append(undo, func() error { return l.RemoveOnuProfileUsage(onuReg.Interface, spName) })
 	}
 	return nil
 }
 
+// GetOnuConfigList performs a Get Request to the l.Host and returns a list of the OnuConfig struct
+func (l *LumiaOlt) GetOnuConfigList() (*OnuConfigList, error) {
+	return l.GetOnuConfigListCtx(context.Background())
+}
+
+// GetOnuConfigListCtx is GetOnuConfigList with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuConfigListCtx(ctx context.Context) (*OnuConfigList, error) {
+	rawJson, err := l.Client.Get(ctx, onuConfig)
+	if err != nil {
+		return nil, err
+	}
+	im, err := l.updateCurrent(onuConfig, rawJson)
+	if err != nil {
+		return nil, err
+	}
+	var list OnuConfigList
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry[i])
+	}
+	return &list, nil
+}
+
 // GetOnuInfoList performs a Get Request to the l.Host and returns a list of the OnuInfo struct
 func (l *LumiaOlt) GetOnuInfoList() (*OnuInfoList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuInfo)
+	return l.GetOnuInfoListCtx(context.Background())
+}
+
+// GetOnuInfoListCtx is GetOnuInfoList with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuInfoListCtx(ctx context.Context) (*OnuInfoList, error) {
+	rawJson, err := l.Client.Get(ctx, onuInfo)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	im, err := l.updateCurrent(onuInfo, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return nil, err
 	}
 	var list OnuInfoList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i])
 	}
 	return &list, nil
 }
 
 // Returns a list of the OnuInfo struct that prefix-match the string (ie 0/1, 0/2...)
 func (l *LumiaOlt) GetOnuInfoListPerPort(port string) (*OnuInfoList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuInfo)
+	return l.GetOnuInfoListPerPortCtx(context.Background(), port)
+}
+
+// GetOnuInfoListPerPortCtx is GetOnuInfoListPerPort with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuInfoListPerPortCtx(ctx context.Context, port string) (*OnuInfoList, error) {
+	rawJson, err := l.Client.Get(ctx, onuInfo)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	im, err := l.updateCurrent(onuInfo, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return nil, err
 	}
 	var list OnuInfoList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry); i++ {
-		if strings.HasPrefix(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i].IfName, port) {
-			list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry); i++ {
+		if strings.HasPrefix(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i].IfName, port) {
+			list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuInfoTable.MsanOnuInfoEntry[i])
 		}
 	}
 	return &list, nil
@@ -784,19 +1104,22 @@ func (l *LumiaOlt) GetOnuInfoListPerPort(port string) (*OnuInfoList, error) {
 
 // GetServiceProfiles performs a Get Request to the l.Host and returns a list of the ServiceProfile struct
 func (l *LumiaOlt) GetServiceProfiles() (*ServiceProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, serviceProfiles)
+	return l.GetServiceProfilesCtx(context.Background())
+}
+
+// GetServiceProfilesCtx is GetServiceProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetServiceProfilesCtx(ctx context.Context) (*ServiceProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, serviceProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, &l.Current)
+	im, err := l.updateCurrent(serviceProfiles, rawJson)
 	if err != nil {
-		l.CacheBack()
 		return nil, err
 	}
 	var list ServiceProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry[i])
 	}
 	return &list, nil
 }
@@ -820,46 +1143,56 @@ func (l *LumiaOlt) GetServiceProfileByName(name string) (*ServiceProfile, error)
 
 // DeleteServiceProfile removes the named ServiceProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteServiceProfile(name string) error {
+	return l.DeleteServiceProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, serviceProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteServiceProfileCtx is DeleteServiceProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteServiceProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, serviceProfiles, name)
 }
 
 // PostServiceProfile performs a Post request to l.Host containing serialized data from a ServiceProfile struct, if the name is not already used
 func (l *LumiaOlt) PostServiceProfile(name string, data []byte) error {
+	return l.PostServiceProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, serviceProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostServiceProfileCtx is PostServiceProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostServiceProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, serviceProfiles, name, data)
+}
+
+// PatchServiceProfile performs a Patch request to l.Host merging serialized ServiceProfile fields into the named profile.
+// Only the fields present in data are changed, so the profile can be repointed at different sub-profiles while in use
+func (l *LumiaOlt) PatchServiceProfile(name string, data []byte) error {
+	return l.PatchServiceProfileCtx(context.Background(), name, data)
+}
+
+// PatchServiceProfileCtx is PatchServiceProfile with its requests bounded by ctx
+func (l *LumiaOlt) PatchServiceProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.patchRecorded(ctx, serviceProfiles, name, data)
 }
 
 // GetFlowProfiles performs a Get Request to the l.Host and returns a list of the FlowProfile struct
 func (l *LumiaOlt) GetFlowProfiles() (*FlowProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, flowProfiles)
+	return l.GetFlowProfilesCtx(context.Background())
+}
+
+// GetFlowProfilesCtx is GetFlowProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetFlowProfilesCtx(ctx context.Context) (*FlowProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, flowProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(flowProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list FlowProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceFlowProfileTable.MsanServiceFlowProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceFlowProfileTable.MsanServiceFlowProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceFlowProfileTable.MsanServiceFlowProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceFlowProfileTable.MsanServiceFlowProfileEntry[i])
 	}
 	return &list, nil
 }
@@ -883,47 +1216,45 @@ func (l *LumiaOlt) GetFlowProfileByName(name string) (*FlowProfile, error) {
 
 // DeleteFlowProfile removes the named FlowProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteFlowProfile(name string) error {
+	return l.DeleteFlowProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, flowProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteFlowProfileCtx is DeleteFlowProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteFlowProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, flowProfiles, name)
 }
 
 // PostFlowProfile performs a Post request to l.Host containing serialized data from a FlowProfile struct, if the name is not already used
 func (l *LumiaOlt) PostFlowProfile(name string, data []byte) error {
+	return l.PostFlowProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, flowProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostFlowProfileCtx is PostFlowProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostFlowProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, flowProfiles, name, data)
 
 }
 
 // GetVlanProfiles performs a Get Request to the l.Host and returns a list of the VlanProfile struct
 func (l *LumiaOlt) GetVlanProfiles() (*VlanProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, vlanProfiles)
+	return l.GetVlanProfilesCtx(context.Background())
+}
+
+// GetVlanProfilesCtx is GetVlanProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetVlanProfilesCtx(ctx context.Context) (*VlanProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, vlanProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(vlanProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list VlanProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanVlanProfileTable.MsanVlanProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanVlanProfileTable.MsanVlanProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanVlanProfileTable.MsanVlanProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanVlanProfileTable.MsanVlanProfileEntry[i])
 	}
 	return &list, nil
 
@@ -945,46 +1276,44 @@ func (l *LumiaOlt) GetVlanProfileByName(name string) (*VlanProfile, error) {
 
 // DeleteVlanProfile removes the named VlanProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteVlanProfile(name string) error {
+	return l.DeleteVlanProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, vlanProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteVlanProfileCtx is DeleteVlanProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteVlanProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, vlanProfiles, name)
 }
 
 // PostVlanProfile performs a Post request to l.Host containing serialized data from a VlanProfile struct, if the name is not already used
 func (l *LumiaOlt) PostVlanProfile(name string, data []byte) error {
+	return l.PostVlanProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, vlanProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostVlanProfileCtx is PostVlanProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostVlanProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, vlanProfiles, name, data)
 }
 
 // GetOnuFlowProfiles performs a Get Request to the l.Host and returns a list of the OnuFlowProfile struct
 func (l *LumiaOlt) GetOnuFlowProfiles() (*OnuFlowProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuFlowProfiles)
+	return l.GetOnuFlowProfilesCtx(context.Background())
+}
+
+// GetOnuFlowProfilesCtx is GetOnuFlowProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuFlowProfilesCtx(ctx context.Context) (*OnuFlowProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, onuFlowProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(onuFlowProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list OnuFlowProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuFlowProfileTable.MsanOnuFlowProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuFlowProfileTable.MsanOnuFlowProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuFlowProfileTable.MsanOnuFlowProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuFlowProfileTable.MsanOnuFlowProfileEntry[i])
 	}
 	return &list, nil
 
@@ -1009,46 +1338,44 @@ func (l *LumiaOlt) GetOnuFlowProfileByName(name string) (*OnuFlowProfile, error)
 
 // DeleteOnuFlowProfile removes the named OnuFlowProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteOnuFlowProfile(name string) error {
+	return l.DeleteOnuFlowProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, onuFlowProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteOnuFlowProfileCtx is DeleteOnuFlowProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteOnuFlowProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, onuFlowProfiles, name)
 }
 
 // PostOnuFlowProfile performs a Post request to l.Host containing serialized data from a OnuFlowProfile struct, if the name is not already used
 func (l *LumiaOlt) PostOnuFlowProfile(name string, data []byte) error {
+	return l.PostOnuFlowProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, onuFlowProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostOnuFlowProfileCtx is PostOnuFlowProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostOnuFlowProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, onuFlowProfiles, name, data)
 }
 
 // GetOnuTcontProfiles performs a Get Request to the l.Host and returns a list of the OnuTcontProfile struct
 func (l *LumiaOlt) GetOnuTcontProfiles() (*OnuTcontProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuTcontProfiles)
+	return l.GetOnuTcontProfilesCtx(context.Background())
+}
+
+// GetOnuTcontProfilesCtx is GetOnuTcontProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuTcontProfilesCtx(ctx context.Context) (*OnuTcontProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, onuTcontProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(onuTcontProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list OnuTcontProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuTcontProfileTable.MsanOnuTcontProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuTcontProfileTable.MsanOnuTcontProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuTcontProfileTable.MsanOnuTcontProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuTcontProfileTable.MsanOnuTcontProfileEntry[i])
 	}
 	return &list, nil
 
@@ -1073,46 +1400,44 @@ func (l *LumiaOlt) GetOnuTcontProfileByName(name string) (*OnuTcontProfile, erro
 
 // DeleteOnuTcontProfile removes the named OnuTcontProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteOnuTcontProfile(name string) error {
+	return l.DeleteOnuTcontProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, onuTcontProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteOnuTcontProfileCtx is DeleteOnuTcontProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteOnuTcontProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, onuTcontProfiles, name)
 }
 
 // PostOnuTcontProfile performs a Post request to l.Host containing serialized data from a OnuTcontProfile struct, if the name is not already used
 func (l *LumiaOlt) PostOnuTcontProfile(name string, data []byte) error {
+	return l.PostOnuTcontProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, onuTcontProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostOnuTcontProfileCtx is PostOnuTcontProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostOnuTcontProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, onuTcontProfiles, name, data)
 }
 
 // GetSecurityProfiles performs a Get Request to the l.Host and returns a list of the SecurityProfile struct
 func (l *LumiaOlt) GetSecurityProfiles() (*SecurityProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, securityProfiles)
+	return l.GetSecurityProfilesCtx(context.Background())
+}
+
+// GetSecurityProfilesCtx is GetSecurityProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetSecurityProfilesCtx(ctx context.Context) (*SecurityProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, securityProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(securityProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list SecurityProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanSecurityProfileTable.MsanSecurityProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanSecurityProfileTable.MsanSecurityProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanSecurityProfileTable.MsanSecurityProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanSecurityProfileTable.MsanSecurityProfileEntry[i])
 	}
 	return &list, nil
 }
@@ -1136,46 +1461,44 @@ func (l *LumiaOlt) GetSecurityProfileByName(name string) (*SecurityProfile, erro
 
 // DeleteSecurityProfile removes the named SecurityProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteSecurityProfile(name string) error {
+	return l.DeleteSecurityProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, securityProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteSecurityProfileCtx is DeleteSecurityProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteSecurityProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, securityProfiles, name)
 }
 
 // PostSecurityProfile performs a Post request to l.Host containing serialized data from a SecurityProfile struct, if the name is not already used
 func (l *LumiaOlt) PostSecurityProfile(name string, data []byte) error {
+	return l.PostSecurityProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, securityProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostSecurityProfileCtx is PostSecurityProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostSecurityProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, securityProfiles, name, data)
 }
 
 // GetMulticastProfiles performs a Get Request to the l.Host and returns a list of the IgmpProfile struct
 func (l *LumiaOlt) GetMulticastProfiles() (*IgmpProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, igmpProfiles)
+	return l.GetMulticastProfilesCtx(context.Background())
+}
+
+// GetMulticastProfilesCtx is GetMulticastProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetMulticastProfilesCtx(ctx context.Context) (*IgmpProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, igmpProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(igmpProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list IgmpProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanMulticastProfileTable.MsanMulticastProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanMulticastProfileTable.MsanMulticastProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanMulticastProfileTable.MsanMulticastProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanMulticastProfileTable.MsanMulticastProfileEntry[i])
 	}
 	return &list, nil
 
@@ -1200,46 +1523,44 @@ func (l *LumiaOlt) GetMulticastProfileByName(name string) (*IgmpProfile, error)
 
 // DeleteMulticastProfile removes the named IgmpProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteMulticastProfile(name string) error {
+	return l.DeleteMulticastProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, igmpProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteMulticastProfileCtx is DeleteMulticastProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteMulticastProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, igmpProfiles, name)
 }
 
 // PostMulticastProfile performs a Post request to l.Host containing serialized data from a IgmpProfile struct, if the name is not already used
 func (l *LumiaOlt) PostMulticastProfile(name string, data []byte) error {
+	return l.PostMulticastProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, igmpProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostMulticastProfileCtx is PostMulticastProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostMulticastProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, igmpProfiles, name, data)
 }
 
 // GetOnuMulticastProfiles performs a Get Request to the l.Host and returns a list of the OnuIgmpProfile struct
 func (l *LumiaOlt) GetOnuMulticastProfiles() (*OnuIgmpProfileList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuIgmpProfiles)
+	return l.GetOnuMulticastProfilesCtx(context.Background())
+}
+
+// GetOnuMulticastProfilesCtx is GetOnuMulticastProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuMulticastProfilesCtx(ctx context.Context) (*OnuIgmpProfileList, error) {
+	rawJson, err := l.Client.Get(ctx, onuIgmpProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(onuIgmpProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list OnuIgmpProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuMulticastProfileTable.MsanOnuMulticastProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuMulticastProfileTable.MsanOnuMulticastProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuMulticastProfileTable.MsanOnuMulticastProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuMulticastProfileTable.MsanOnuMulticastProfileEntry[i])
 	}
 	return &list, nil
 }
@@ -1263,51 +1584,49 @@ func (l *LumiaOlt) GetOnuMulticastProfileByName(name string) (*OnuIgmpProfile, e
 
 // DeleteOnuMulticastProfile removes the named OnuIgmpProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteOnuMulticastProfile(name string) error {
+	return l.DeleteOnuMulticastProfileCtx(context.Background(), name)
+}
 
-	resp, err := RestDeleteProfile(l.Host, onuIgmpProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// DeleteOnuMulticastProfileCtx is DeleteOnuMulticastProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteOnuMulticastProfileCtx(ctx context.Context, name string) error {
+
+	return l.deleteChecked(ctx, onuIgmpProfiles, name)
 }
 
 // PostOnuMulticastProfile performs a Post request to l.Host containing serialized data from a OnuIgmpProfile struct, if the name is not already used
 func (l *LumiaOlt) PostOnuMulticastProfile(name string, data []byte) error {
+	return l.PostOnuMulticastProfileCtx(context.Background(), name, data)
+}
 
-	resp, err := RestPostProfile(l.Host, onuIgmpProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+// PostOnuMulticastProfileCtx is PostOnuMulticastProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostOnuMulticastProfileCtx(ctx context.Context, name string, data []byte) error {
+
+	return l.postRecorded(ctx, onuIgmpProfiles, name, data)
 
 }
 
 // GetOnuVlanProfiles performs a Get Request to the l.Host and returns a list of the OnuVlanProfile struct including the OnuVlanRuleList that it nests
 func (l *LumiaOlt) GetOnuVlanProfiles() (*OnuVlanProfileList, *OnuVlanRuleList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuVlanProfiles)
+	return l.GetOnuVlanProfilesCtx(context.Background())
+}
+
+// GetOnuVlanProfilesCtx is GetOnuVlanProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuVlanProfilesCtx(ctx context.Context) (*OnuVlanProfileList, *OnuVlanRuleList, error) {
+	rawJson, err := l.Client.Get(ctx, onuVlanProfiles)
 	if err != nil {
 		return nil, nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(onuVlanProfiles, rawJson)
 	if err != nil {
 		return nil, nil, err
 	}
 	var list OnuVlanProfileList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileTable.MsanOnuVlanProfileEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileTable.MsanOnuVlanProfileEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileTable.MsanOnuVlanProfileEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileTable.MsanOnuVlanProfileEntry[i])
 		//fmt.Println(list)
 	}
 	var rules *OnuVlanRuleList
-	rules, err = l.GetOnuVlanRules()
+	rules, err = l.GetOnuVlanRulesCtx(ctx)
 	if err != nil {
 		return &list, nil, err
 	}
@@ -1332,10 +1651,15 @@ func (l *LumiaOlt) GetOnuVlanProfiles() (*OnuVlanProfileList, *OnuVlanRuleList,
 
 // GetOnuVlanProfileByName is a helper method that returns a single OnuVlanProfile struct by name, if exists
 func (l *LumiaOlt) GetOnuVlanProfileByName(name string) (*OnuVlanProfile, error) {
+	return l.GetOnuVlanProfileByNameCtx(context.Background(), name)
+}
+
+// GetOnuVlanProfileByNameCtx is GetOnuVlanProfileByName with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuVlanProfileByNameCtx(ctx context.Context, name string) (*OnuVlanProfile, error) {
 	if name == "" {
 		return nil, ErrNotInput
 	}
-	list, _, err := l.GetOnuVlanProfiles()
+	list, _, err := l.GetOnuVlanProfilesCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1349,8 +1673,13 @@ func (l *LumiaOlt) GetOnuVlanProfileByName(name string) (*OnuVlanProfile, error)
 
 // DeleteOnuVlanProfile removes the named OnuVlanProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteOnuVlanProfile(name string) error {
+	return l.DeleteOnuVlanProfileCtx(context.Background(), name)
+}
+
+// DeleteOnuVlanProfileCtx is DeleteOnuVlanProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteOnuVlanProfileCtx(ctx context.Context, name string) error {
 	// get individual profile by supplied name value, if exists
-	p, err := l.GetOnuVlanProfileByName(name)
+	p, err := l.GetOnuVlanProfileByNameCtx(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -1358,52 +1687,45 @@ func (l *LumiaOlt) DeleteOnuVlanProfile(name string) error {
 	if p.Usage == 1 {
 		return ErrInUse
 	}
-	// perform the delete operation
-	resp, err := RestDeleteProfile(l.Host, onuVlanProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	// perform the delete operation, l.Graph when set names what still references the profile
+	return l.deleteChecked(ctx, onuVlanProfiles, name)
 }
 
 // PostOnuVlanProfile performs a Post request to l.Host containing serialized data from a OnuVlanProfile struct, if the name is not already used
 func (l *LumiaOlt) PostOnuVlanProfile(name string, data []byte) error {
+	return l.PostOnuVlanProfileCtx(context.Background(), name, data)
+}
+
+// PostOnuVlanProfileCtx is PostOnuVlanProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostOnuVlanProfileCtx(ctx context.Context, name string, data []byte) error {
 	// check if name is already in use
-	_, err := l.GetOnuVlanProfileByName(name)
+	_, err := l.GetOnuVlanProfileByNameCtx(ctx, name)
 	if err == nil {
 		return ErrExists
 	}
 	// The OnuVlanProfile has a method called GenerateJson() that serializes the data as input
 	// perform the post operation
-	resp, err := RestPostProfile(l.Host, onuVlanProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	return l.postRecorded(ctx, onuVlanProfiles, name, data)
 }
 
 // GetOnuVlanRules performs a Get Request to the l.Host and returns a list of the OnuVlanRule struct
 func (l *LumiaOlt) GetOnuVlanRules() (*OnuVlanRuleList, error) {
-	rawJson, err := RestGetProfiles(l.Host, onuVlanRules)
+	return l.GetOnuVlanRulesCtx(context.Background())
+}
+
+// GetOnuVlanRulesCtx is GetOnuVlanRules with its requests bounded by ctx
+func (l *LumiaOlt) GetOnuVlanRulesCtx(ctx context.Context) (*OnuVlanRuleList, error) {
+	rawJson, err := l.Client.Get(ctx, onuVlanRules)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(onuVlanRules, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list OnuVlanRuleList
-	for i := 0; i < len(l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry); i++ {
-		list.Entry = append(list.Entry, &l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry[i])
+	for i := 0; i < len(im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry); i++ {
+		list.Entry = append(list.Entry, &im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry[i])
 	}
 	return &list, nil
 }
@@ -1438,15 +1760,7 @@ func (l *LumiaOlt) DeleteOnuVlanRule(name string) error {
 		return ErrInUse
 	}
 	// perform the delete operation
-	resp, err := RestDeleteProfile(l.Host, onuVlanRules, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	return l.Client.Delete(context.Background(), onuVlanRules, name)
 }
 
 // CANNOT POST RULES INDEPENDENT OF THE ONUVLANPROFILE, THESE FUNCTIONS ARE A SUBSET OF THAT PROFILE'S OPERATIONS
@@ -1459,31 +1773,27 @@ func (l *LumiaOlt) PostOnuVlanRule(name string, data []byte) error {
 	}
 	// The OnuVlanRule has a method called GenerateJson() that serializes the data as input
 	// perform the post operation
-	resp, err := RestPostProfile(l.Host, onuVlanRules, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	return l.Client.Post(context.Background(), onuVlanRules, name, data)
 }
 */
 
 // GetL2cpProfiles performs a Get Request to the l.Host and returns a list of the L2cpProfile struct
 func (l *LumiaOlt) GetL2cpProfiles() ([]*L2cpProfile, error) {
-	rawJson, err := RestGetProfiles(l.Host, l2cpProfiles)
+	return l.GetL2cpProfilesCtx(context.Background())
+}
+
+// GetL2cpProfilesCtx is GetL2cpProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetL2cpProfilesCtx(ctx context.Context) ([]*L2cpProfile, error) {
+	rawJson, err := l.Client.Get(ctx, l2cpProfiles)
 	if err != nil {
 		return nil, err
 	}
-	l.CacheSwap()
-	err = json.Unmarshal(rawJson, l.Current)
+	im, err := l.updateCurrent(l2cpProfiles, rawJson)
 	if err != nil {
 		return nil, err
 	}
 	var list []*L2cpProfile
-	for _, v := range l.Current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanL2CpProfileTable.MsanL2CpProfileEntry {
+	for _, v := range im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanL2CpProfileTable.MsanL2CpProfileEntry {
 		list = append(list, &v)
 	}
 	return list, nil
@@ -1491,10 +1801,15 @@ func (l *LumiaOlt) GetL2cpProfiles() ([]*L2cpProfile, error) {
 
 // GetL2cpProfileByName is a helper method that returns a single L2cpProfile struct by name, if exists
 func (l *LumiaOlt) GetL2cpProfileByName(name string) (*L2cpProfile, error) {
+	return l.GetL2cpProfileByNameCtx(context.Background(), name)
+}
+
+// GetL2cpProfileByNameCtx is GetL2cpProfileByName with its requests bounded by ctx
+func (l *LumiaOlt) GetL2cpProfileByNameCtx(ctx context.Context, name string) (*L2cpProfile, error) {
 	if name == "" {
 		return nil, ErrNotInput
 	}
-	list, err := l.GetL2cpProfiles()
+	list, err := l.GetL2cpProfilesCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1508,8 +1823,13 @@ func (l *LumiaOlt) GetL2cpProfileByName(name string) (*L2cpProfile, error) {
 
 // DeleteL2cpProfile removes the named L2cpProfile from the l.Host if it exists and is not in use by a ServiceProfile
 func (l *LumiaOlt) DeleteL2cpProfile(name string) error {
+	return l.DeleteL2cpProfileCtx(context.Background(), name)
+}
+
+// DeleteL2cpProfileCtx is DeleteL2cpProfile with its requests bounded by ctx
+func (l *LumiaOlt) DeleteL2cpProfileCtx(ctx context.Context, name string) error {
 	// get individual profile by supplied name value, if exists
-	p, err := l.GetL2cpProfileByName(name)
+	p, err := l.GetL2cpProfileByNameCtx(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -1517,34 +1837,23 @@ func (l *LumiaOlt) DeleteL2cpProfile(name string) error {
 	if p.Usage == 1 {
 		return ErrInUse
 	}
-	// perform the delete operation
-	resp, err := RestDeleteProfile(l.Host, l2cpProfiles, name)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	// perform the delete operation, l.Graph when set names what still references the profile
+	return l.deleteChecked(ctx, l2cpProfiles, name)
 }
 
 // PostL2cpProfile performs a Post request to l.Host containing serialized data from a L2cpProfile struct, if the name is not already used
 func (l *LumiaOlt) PostL2cpProfile(name string, data []byte) error {
+	return l.PostL2cpProfileCtx(context.Background(), name, data)
+}
+
+// PostL2cpProfileCtx is PostL2cpProfile with its requests bounded by ctx
+func (l *LumiaOlt) PostL2cpProfileCtx(ctx context.Context, name string, data []byte) error {
 	// check if name is already in use
-	_, err := l.GetL2cpProfileByName(name)
+	_, err := l.GetL2cpProfileByNameCtx(ctx, name)
 	if err == nil {
 		return ErrExists
 	}
 	// The L2cpProfile has a method called GenerateJson() that serializes the data as input
 	// perform the post operation
-	resp, err := RestPostProfile(l.Host, l2cpProfiles, name, data)
-	if err != nil {
-		return err
-	}
-	if resp != responseOk {
-		fmt.Println(resp)
-		return ErrNotStatusOk
-	}
-	return nil
+	return l.postRecorded(ctx, l2cpProfiles, name, data)
 }
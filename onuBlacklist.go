@@ -6,6 +6,14 @@ import (
 	"text/tabwriter"
 )
 
+// Blacklist causes reported in OnuBlacklist.Cause
+const (
+	BlCauseInvalid          = 1
+	BlCauseSnNotKnown       = 2
+	BlCausePasswordMismatch = 3 // configured for the Serial Number with a different password
+	BlCausePonLinkMismatch  = 6 // configured for the Serial Number on another Olt interface
+)
+
 type OnuBlacklist struct {
 	IfName       string `json:"msanOnuBlackListIfName"`
 	SerialNumber string `json:"msanOnuBlackListSerialNumber"`
@@ -31,13 +39,13 @@ func (bl *OnuBlacklist) GetPassword() string {
 
 func (bl *OnuBlacklist) GetBlCause() string {
 	switch {
-	case bl.Cause == 1 {
+	case bl.Cause == BlCauseInvalid:
 		return "Invalid"
-	case bl.Cause == 2 {
+	case bl.Cause == BlCauseSnNotKnown:
 		return "SN Not Known"
-	case bl.Cause == 3 {
+	case bl.Cause == BlCausePasswordMismatch:
 		return "Password Mismatch"
-	case bl.Cause == 6 {
+	case bl.Cause == BlCausePonLinkMismatch:
 		return "PON Link Mismatch"
 	default:
 		return "Unknown"
@@ -4,6 +4,12 @@ import (
 	"encoding/json"
 )
 
+// values of OnuConfig.AdminState
+const (
+	OnuAdminUp   = 1
+	OnuAdminDown = 2
+)
+
 type OnuConfig struct {
 	IfName                 string `json:"msanOnuCfgIfName"`
 	Password               string `json:"msanOnuCfgPassword"`
@@ -50,6 +56,20 @@ func NewOnuConfig(sn, intf string) *OnuConfig {
 	return o
 }
 
+// NewOnuConfigWithPassword provides an OnuConfig that also requires the Onu to present the supplied password.
+// With an empty Serial Number any Onu presenting the password on the Olt interface is accepted,
+// so the password serves as a registration ID typed into the Onu by the installer
+func NewOnuConfigWithPassword(sn, password, intf string) *OnuConfig {
+	o := NewOnuConfig(sn, intf)
+	o.Password = password
+	return o
+}
+
+// authKey identifies the Onu an OnuConfig authorizes: the Serial Number, or the password when authorizing by password only
+func (o *OnuConfig) authKey() string {
+	return registerKey(o.SerialNumber, o.Password)
+}
+
 // GenerateBlankConfig allows a configured Onu to be removed by patching the interface with a blank serial number
 func GenerateBlankConfig(intf string) *OnuConfig {
 	o := &OnuConfig{
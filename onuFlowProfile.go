@@ -54,10 +54,10 @@ func (p *OnuFlowProfile) Copy(newName string) (*OnuFlowProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 // GetMatchUsCVlanIDRange returns the values set to match with Customer VLAN ID in the OnuFlowProfile
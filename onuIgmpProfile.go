@@ -48,10 +48,10 @@ func (p *OnuIgmpProfile) Copy(newName string) (*OnuIgmpProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 func (p *OnuIgmpProfile) GetMode() string {
@@ -56,6 +56,45 @@ func (o *OnuInfo) IsUp() bool {
         return o.OperState == 1
 }
 
+// GetOperState returns the OperState as text
+func (o *OnuInfo) GetOperState() string {
+        if o.IsUp() {
+                return "up"
+        }
+        return "down"
+}
+
+// RxPowerDbm returns the optical power received by the Onu in dBm; the raw values are in hundredths of a dBm
+func (o *OnuInfo) RxPowerDbm() float64 {
+        return float64(o.RxPower) / 100
+}
+
+// TxPowerDbm returns the optical power transmitted by the Onu in dBm
+func (o *OnuInfo) TxPowerDbm() float64 {
+        return float64(o.TxPower) / 100
+}
+
+// OltRxPowerDbm returns the optical power of the Onu received by the Olt in dBm
+func (o *OnuInfo) OltRxPowerDbm() float64 {
+        return float64(o.OltRxPower) / 100
+}
+
+// TempCelsius returns the temperature of the Onu optics in degrees Celsius
+func (o *OnuInfo) TempCelsius() float64 {
+        return float64(o.Temp)
+}
+
+// Firmware returns the version of the active software image, or the Onu Version if no image is marked active
+func (o *OnuInfo) Firmware() string {
+        switch {
+        case o.OnuImageInstance0Activate == 1:
+                return o.OnuImageInstance0Version
+        case o.OnuImageInstance1Activate == 1:
+                return o.OnuImageInstance1Version
+        }
+        return o.Version
+}
+
 // GenerateJson serializes the data structure so it can be set with Restconf
 func (o *OnuInfo) GenerateJson() (intf string, data []byte) {
         data, err := json.Marshal(o)
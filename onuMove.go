@@ -0,0 +1,115 @@
+package goPon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MoveOnu moves a configured Onu to another Olt interface (0/x), where the next available Onu Subinterface is allocated,
+// or to a specific Onu interface (0/x/y) that is not configured. The OnuConfig and every Service Profile binding are moved
+// and the source interface is cleared. If a step fails the steps already made are undone, and the OnuRegister of the Onu
+// is only changed once the move has succeeded. The Onu interface it was moved to is returned
+func (l *LumiaOlt) MoveOnu(sn, target string) (string, error) {
+	return l.MoveOnuCtx(context.Background(), sn, target)
+}
+
+// MoveOnuCtx is MoveOnu with its requests bounded by ctx. The completed steps are undone even once ctx is done
+func (l *LumiaOlt) MoveOnuCtx(ctx context.Context, sn, target string) (string, error) {
+	if sn == "" {
+		return "", ErrNotInput
+	}
+	ocl, err := l.GetOnuConfigListCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	var src *OnuConfig
+	configured := make(map[string]bool)
+	for _, c := range ocl.Entry {
+		if c.SerialNumber == sn {
+			src = c
+		}
+		if c.SerialNumber != "" || c.Password != "" {
+			configured[c.IfName] = true
+		}
+	}
+	if src == nil {
+		return "", fmt.Errorf("%w: %s is not configured", ErrNotExists, sn)
+	}
+	var intf string
+	switch strings.Count(target, "/") {
+	case 1:
+		intf = l.NextAvailableOnuInterface(target)
+		// the interface is registered to the Onu on success, or handed back
+		defer l.ReleaseOnuInterface(intf)
+	case 2:
+		intf = target
+	default:
+		return "", fmt.Errorf("%w: %s", ErrNotInput, target)
+	}
+	if configured[intf] {
+		return "", fmt.Errorf("%w: %s is configured", ErrExists, intf)
+	}
+	opl, err := l.GetOnuProfileUsageCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+	var services []string
+	for _, op := range opl.Entry {
+		if op.IfName == src.IfName {
+			services = append(services, op.ServiceProfileName)
+		}
+	}
+
+	// the bindings and config are cleared from the source first, as the OLT accepts a Serial Number on one interface only
+	orig := *src
+	moved := *src
+	moved.IfName = intf
+	var undo []func() error
+	for _, sp := range services {
+		err = l.RemoveOnuProfileUsageCtx(ctx, orig.IfName, sp)
+		if err != nil {
+			return "", l.rollback(err, undo)
+		}
+		spName := sp
+		undo = append(undo, func() error { return l.PostOnuProfile(NewOnuProfile(orig.IfName, spName)) })
+	}
+	err = l.AuthorizeOnuOverrideCtx(ctx, GenerateBlankConfig(orig.IfName))
+	if err != nil {
+		return "", l.rollback(err, undo)
+	}
+	undo = append(undo, func() error { return l.AuthorizeOnuOverride(&orig) })
+	err = l.AuthorizeOnuOverrideCtx(ctx, &moved)
+	if err != nil {
+		return "", l.rollback(err, undo)
+	}
+	undo = append(undo, func() error { return l.AuthorizeOnuOverride(GenerateBlankConfig(intf)) })
+	for _, sp := range services {
+		err = l.PostOnuProfileCtx(ctx, NewOnuProfile(intf, sp))
+		if err != nil {
+			return "", l.rollback(fmt.Errorf("%s: %w", sp, err), undo)
+		}
+		spName := sp
+		undo = append(undo, func() error { return l.RemoveOnuProfileUsage(intf, spName) })
+	}
+	err = l.moveRegister(&moved, services)
+	if err != nil {
+		return "", err
+	}
+	return intf, nil
+}
+
+// moveRegister points the OnuRegister of a moved Onu at its new interface and services, adding one if it had none
+func (l *LumiaOlt) moveRegister(ocfg *OnuConfig, services []string) error {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	delete(l.reserved, ocfg.IfName)
+	onuReg, err := l.onuRegisterBySn(ocfg.SerialNumber)
+	if err != nil {
+		onuReg = l.addRegister(&OnuRegister{SerialNumber: ocfg.SerialNumber, Password: ocfg.Password})
+	}
+	onuReg.Interface = ocfg.IfName
+	onuReg.Services = services
+	onuReg.activate()
+	return l.saveRegistry()
+}
@@ -0,0 +1,96 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ReplaceOnu puts a new device in place of a failed one: only the SerialNumber of the OnuConfig oldSn is configured with
+// is patched, so the interface, its password and its Service Profile bindings are kept.
+// The new Serial Number must be waiting on the Blacklist of the same PON port and must not be configured on any interface
+func (l *LumiaOlt) ReplaceOnu(oldSn, newSn string) error {
+	return l.ReplaceOnuCtx(context.Background(), oldSn, newSn)
+}
+
+// ReplaceOnuCtx is ReplaceOnu with its requests bounded by ctx
+func (l *LumiaOlt) ReplaceOnuCtx(ctx context.Context, oldSn, newSn string) error {
+	if oldSn == "" || newSn == "" || oldSn == newSn {
+		return ErrNotInput
+	}
+	ocl, err := l.GetOnuConfigListCtx(ctx)
+	if err != nil {
+		return err
+	}
+	var cfg *OnuConfig
+	for _, c := range ocl.Entry {
+		switch c.SerialNumber {
+		case oldSn:
+			cfg = c
+		case newSn:
+			return fmt.Errorf("%w: %s is configured on %s", ErrExists, newSn, c.IfName)
+		}
+	}
+	if cfg == nil {
+		return fmt.Errorf("%w: %s is not configured", ErrNotExists, oldSn)
+	}
+	i := strings.LastIndex(cfg.IfName, "/")
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotInput, cfg.IfName)
+	}
+	port := cfg.IfName[:i]
+	obll, err := l.GetOnuBlacklistCtx(ctx)
+	if err != nil {
+		return err
+	}
+	var bl *OnuBlacklist
+	for _, e := range obll.Entry {
+		if e.SerialNumber == newSn && e.IfName == port {
+			bl = e
+		}
+	}
+	if bl == nil {
+		return fmt.Errorf("%w: %s is not on the Blacklist of %s", ErrNotExists, newSn, port)
+	}
+	if cfg.Password != "" && bl.Password != cfg.Password {
+		return fmt.Errorf("%w: %s", ErrPasswordMismatch, newSn)
+	}
+	// the key along with the one changed leaf, leaving the rest of the config as it is
+	data, err := json.Marshal(struct {
+		IfName       string `json:"msanOnuCfgIfName"`
+		SerialNumber string `json:"msanOnuCfgSerialNumber"`
+	}{cfg.IfName, newSn})
+	if err != nil {
+		return err
+	}
+	err = l.Client.Patch(ctx, onuConfig, UrlEncodeInterface(cfg.IfName), data)
+	if err != nil {
+		return err
+	}
+	return l.replaceRegister(oldSn, newSn, cfg.IfName)
+}
+
+// replaceRegister moves the OnuRegister of oldSn to newSn, dropping any entry newSn had on the Authorized List
+// as the services and subscriber are now those of the interface. Removed records of newSn are kept as its history,
+// and the Registration index is saved
+func (l *LumiaOlt) replaceRegister(oldSn, newSn, intf string) error {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	kept := l.Registration[:0]
+	for _, r := range l.Registration {
+		if r.SerialNumber != newSn || r.State == StateRemoved {
+			kept = append(kept, r)
+		}
+	}
+	l.Registration = kept
+	onuReg, err := l.onuRegisterBySn(oldSn)
+	if err != nil {
+		l.addRegister(&OnuRegister{SerialNumber: newSn, Interface: intf}).activate()
+		return l.saveRegistry()
+	}
+	onuReg.SerialNumber = newSn
+	onuReg.Interface = intf
+	onuReg.activate()
+	return l.saveRegistry()
+}
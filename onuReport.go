@@ -0,0 +1,191 @@
+package goPon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// registration states of an OnuReport
+const (
+	ReportConfigured  = "configured"
+	ReportDisabled    = "disabled" // configured with its AdminState down, such as by SuspendOnu
+	ReportBlacklisted = "blacklisted"
+)
+
+// OnuReport is what an Olt knows of a Serial Number at one interface, gathered from the config, Blacklist and info tables
+type OnuReport struct {
+	Olt            string // name of the Olt in a Fleet, or its Host
+	Site           string
+	SerialNumber   string
+	Interface      string   // Onu interface (0/x/y) it is configured on, or the Olt interface (0/x) of its Blacklist entry
+	Registration   string   // ReportConfigured, ReportDisabled or ReportBlacklisted
+	BlacklistCause string   // GetBlCause of the Blacklist entry
+	Services       []string // Service Profiles bound to the interface
+	Info           *OnuInfo // info table entry of the interface, if any
+}
+
+type OnuReportList struct {
+	Entry []*OnuReport
+}
+
+// FindOnu reports every interface of the Olt the Serial Number is configured on, registered on, or waiting on the Blacklist of.
+// An Onu authorized by password only is found through the info table once it has registered.
+// The list is empty if the Olt does not know the Serial Number
+func (l *LumiaOlt) FindOnu(sn string) (*OnuReportList, error) {
+	return l.FindOnuCtx(context.Background(), sn)
+}
+
+// FindOnuCtx is FindOnu with its requests bounded by ctx
+func (l *LumiaOlt) FindOnuCtx(ctx context.Context, sn string) (*OnuReportList, error) {
+	if sn == "" {
+		return nil, ErrNotInput
+	}
+	ocl, err := l.GetOnuConfigListCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	oil, err := l.GetOnuInfoListCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obll, err := l.GetOnuBlacklistCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opl, err := l.GetOnuProfileUsageCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfgs := make(map[string]*OnuConfig)
+	for _, c := range ocl.Entry {
+		cfgs[c.IfName] = c
+	}
+	list := &OnuReportList{}
+	byIntf := make(map[string]*OnuReport)
+	configured := func(intf string) *OnuReport {
+		if r, ok := byIntf[intf]; ok {
+			return r
+		}
+		r := &OnuReport{Olt: l.Host, SerialNumber: sn, Interface: intf, Registration: ReportConfigured}
+		if c, ok := cfgs[intf]; ok && c.AdminState == OnuAdminDown {
+			r.Registration = ReportDisabled
+		}
+		for _, op := range opl.Entry {
+			if op.IfName == intf {
+				r.Services = append(r.Services, op.ServiceProfileName)
+			}
+		}
+		byIntf[intf] = r
+		list.Entry = append(list.Entry, r)
+		return r
+	}
+	for _, c := range ocl.Entry {
+		if c.SerialNumber == sn {
+			configured(c.IfName)
+		}
+	}
+	for _, i := range oil.Entry {
+		if i.SerialNumber == sn {
+			configured(i.IfName).Info = i
+		}
+	}
+	for _, e := range obll.Entry {
+		if e.SerialNumber == sn {
+			list.Entry = append(list.Entry, &OnuReport{Olt: l.Host, SerialNumber: sn, Interface: e.IfName, Registration: ReportBlacklisted, BlacklistCause: e.GetBlCause()})
+		}
+	}
+	return list, nil
+}
+
+// FindOnu reports the Serial Number on every Olt matching the filter, see LumiaOlt.FindOnu
+func (f *Fleet) FindOnu(ctx context.Context, ff FleetFilter, sn string) (*OnuReportList, error) {
+	if sn == "" {
+		return nil, ErrNotInput
+	}
+	results, err := f.Query(ctx, ff, func(ctx context.Context, l *LumiaOlt) (interface{}, error) {
+		return l.FindOnuCtx(ctx, sn)
+	})
+	list := &OnuReportList{}
+	for _, r := range results {
+		for _, o := range r.Value.(*OnuReportList).Entry {
+			o.Olt, o.Site = r.Olt.Name, r.Olt.Site
+			list.Entry = append(list.Entry, o)
+		}
+	}
+	return list, err
+}
+
+var OnuReportHeaders = []string{
+	"OLT",
+	"Site",
+	"Interface",
+	"Registration",
+	"Blacklist Cause",
+	"Oper State",
+	"Rx dBm",
+	"Tx dBm",
+	"OLT Rx dBm",
+	"Temp C",
+	"Firmware",
+	"Service Profiles",
+}
+
+// ListEssentialParams returns the values of the report keyed by OnuReportHeaders.
+// Optical levels are only shown while the Onu is up, as the info table holds no live readings otherwise
+func (r *OnuReport) ListEssentialParams() map[string]interface{} {
+	var EssentialOnuReport = map[string]interface{}{
+		OnuReportHeaders[0]:  r.Olt,
+		OnuReportHeaders[1]:  r.Site,
+		OnuReportHeaders[2]:  r.Interface,
+		OnuReportHeaders[3]:  r.Registration,
+		OnuReportHeaders[4]:  r.BlacklistCause,
+		OnuReportHeaders[11]: strings.Join(r.Services, ", "),
+	}
+	if r.Info != nil {
+		EssentialOnuReport[OnuReportHeaders[5]] = r.Info.GetOperState()
+		EssentialOnuReport[OnuReportHeaders[10]] = r.Info.Firmware()
+		if r.Info.IsUp() {
+			EssentialOnuReport[OnuReportHeaders[6]] = fmt.Sprintf("%.2f", r.Info.RxPowerDbm())
+			EssentialOnuReport[OnuReportHeaders[7]] = fmt.Sprintf("%.2f", r.Info.TxPowerDbm())
+			EssentialOnuReport[OnuReportHeaders[8]] = fmt.Sprintf("%.2f", r.Info.OltRxPowerDbm())
+			EssentialOnuReport[OnuReportHeaders[9]] = fmt.Sprintf("%.0f", r.Info.TempCelsius())
+		}
+	}
+	return EssentialOnuReport
+}
+
+// Tabwrite displays every interface the Serial Number was found on in organized columns
+func (orl *OnuReportList) Tabwrite() {
+	sn := ""
+	if len(orl.Entry) > 0 {
+		sn = orl.Entry[0].SerialNumber
+	}
+	fmt.Printf("|| Onu Report %s ||\n", sn)
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range OnuReportHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range OnuReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, r := range orl.Entry {
+		data := r.ListEssentialParams()
+		for _, v := range OnuReportHeaders {
+			if data[v] == nil {
+				data[v] = ""
+			}
+			fmt.Fprintf(tw, "%v\t", data[v])
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	for _, v := range OnuReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
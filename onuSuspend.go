@@ -0,0 +1,179 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SuspendOnu takes a configured Onu out of service without deauthorizing it. The Service Profile bindings of its interface
+// are recorded in the OnuRegister, then swapped for the l.WalledGarden Service Profile when one is set,
+// or left in place while the Onu is disabled by its AdminState otherwise. If a step fails the steps already made are undone.
+// The recorded bindings are only kept by a RegistryStore, so one must be open for the suspension to be resumed in a later run
+func (l *LumiaOlt) SuspendOnu(sn string) error {
+	return l.SuspendOnuCtx(context.Background(), sn)
+}
+
+// SuspendOnuCtx is SuspendOnu with its requests bounded by ctx. Steps already made are undone even once ctx is done
+func (l *LumiaOlt) SuspendOnuCtx(ctx context.Context, sn string) error {
+	if l.Store == nil {
+		return fmt.Errorf("%w: a RegistryStore must be open to keep the suspended services", ErrNotInput)
+	}
+	intf, err := l.suspendTarget(sn, false)
+	if err != nil {
+		return err
+	}
+	services, err := l.onuBindings(ctx, intf)
+	if err != nil {
+		return err
+	}
+	var undo []func() error
+	if l.WalledGarden == "" {
+		err = l.setOnuAdminState(ctx, intf, OnuAdminDown)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, sp := range services {
+			err = l.RemoveOnuProfileUsageCtx(ctx, intf, sp)
+			if err != nil {
+				return l.rollback(err, undo)
+			}
+			spName := sp
+			undo = append(undo, func() error { return l.PostOnuProfile(NewOnuProfile(intf, spName)) })
+		}
+		err = l.PostOnuProfileCtx(ctx, NewOnuProfile(intf, l.WalledGarden))
+		if err != nil {
+			return l.rollback(fmt.Errorf("%s: %w", l.WalledGarden, err), undo)
+		}
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg, err := l.onuRegisterBySn(sn)
+	if err != nil {
+		return err
+	}
+	onuReg.SuspendedServices = services
+	onuReg.WalledGarden = l.WalledGarden
+	if l.WalledGarden != "" {
+		onuReg.Services = []string{l.WalledGarden}
+	}
+	onuReg.State = StateSuspended
+	return l.saveRegistry()
+}
+
+// ResumeOnu returns a suspended Onu to service: the Service Profile bindings recorded by SuspendOnu are restored exactly,
+// removing any other binding of the interface such as the walled garden, and the Onu is enabled.
+// If a step fails the steps already made are undone and the Onu stays suspended
+func (l *LumiaOlt) ResumeOnu(sn string) error {
+	return l.ResumeOnuCtx(context.Background(), sn)
+}
+
+// ResumeOnuCtx is ResumeOnu with its requests bounded by ctx. Steps already made are undone even once ctx is done
+func (l *LumiaOlt) ResumeOnuCtx(ctx context.Context, sn string) error {
+	intf, err := l.suspendTarget(sn, true)
+	if err != nil {
+		return err
+	}
+	onuReg, err := l.GetOnuRegisterBySn(sn)
+	if err != nil {
+		return err
+	}
+	l.regMu.Lock()
+	services := append([]string(nil), onuReg.SuspendedServices...)
+	l.regMu.Unlock()
+	current, err := l.onuBindings(ctx, intf)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool)
+	for _, sp := range services {
+		want[sp] = true
+	}
+	have := make(map[string]bool)
+	var undo []func() error
+	for _, sp := range current {
+		have[sp] = true
+		if want[sp] {
+			continue
+		}
+		err = l.RemoveOnuProfileUsageCtx(ctx, intf, sp)
+		if err != nil {
+			return l.rollback(err, undo)
+		}
+		spName := sp
+		undo = append(undo, func() error { return l.PostOnuProfile(NewOnuProfile(intf, spName)) })
+	}
+	for _, sp := range services {
+		if have[sp] {
+			continue
+		}
+		err = l.PostOnuProfileCtx(ctx, NewOnuProfile(intf, sp))
+		if err != nil {
+			return l.rollback(fmt.Errorf("%s: %w", sp, err), undo)
+		}
+		spName := sp
+		undo = append(undo, func() error { return l.RemoveOnuProfileUsage(intf, spName) })
+	}
+	err = l.setOnuAdminState(ctx, intf, OnuAdminUp)
+	if err != nil {
+		return l.rollback(err, undo)
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg.Services = services
+	onuReg.SuspendedServices = nil
+	onuReg.WalledGarden = ""
+	onuReg.State = StateActive
+	return l.saveRegistry()
+}
+
+// suspendTarget returns the interface of the configured Onu sn, which must be suspended or not as required
+func (l *LumiaOlt) suspendTarget(sn string, suspended bool) (string, error) {
+	if sn == "" {
+		return "", ErrNotInput
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	onuReg, err := l.onuRegisterBySn(sn)
+	if err != nil {
+		return "", err
+	}
+	if onuReg.Interface == "" {
+		return "", fmt.Errorf("%w: %s is not configured", ErrNotExists, sn)
+	}
+	if suspended && onuReg.State != StateSuspended {
+		return "", fmt.Errorf("%w: %s is not suspended", ErrNotExists, sn)
+	}
+	if !suspended && onuReg.State == StateSuspended {
+		return "", fmt.Errorf("%w: %s is already suspended", ErrExists, sn)
+	}
+	return onuReg.Interface, nil
+}
+
+// onuBindings returns the Service Profiles bound to the Onu interface on the Olt
+func (l *LumiaOlt) onuBindings(ctx context.Context, intf string) ([]string, error) {
+	opl, err := l.GetOnuProfileUsageCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, op := range opl.Entry {
+		if op.IfName == intf {
+			services = append(services, op.ServiceProfileName)
+		}
+	}
+	return services, nil
+}
+
+// setOnuAdminState patches the AdminState of the OnuConfig of the interface, leaving the rest of the config as it is
+func (l *LumiaOlt) setOnuAdminState(ctx context.Context, intf string, state int) error {
+	data, err := json.Marshal(struct {
+		IfName     string `json:"msanOnuCfgIfName"`
+		AdminState int    `json:"msanOnuCfgAdminState"`
+	}{intf, state})
+	if err != nil {
+		return err
+	}
+	return l.Client.Patch(ctx, onuConfig, UrlEncodeInterface(intf), data)
+}
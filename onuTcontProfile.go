@@ -50,10 +50,10 @@ func (p *OnuTcontProfile) Copy(newName string) (*OnuTcontProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 // GenerateTcontName returns a string of the suggested naming convention based on profile details
@@ -74,6 +74,26 @@ func (p *OnuVlanProfile) IsUsed() bool {
 	return p.Usage == 1
 }
 
+// Copy returns a copy of the profile object with a new name and Usage set to 2.
+// The Rules are copied as well and renamed to the new profile
+func (p *OnuVlanProfile) Copy(newName string) (*OnuVlanProfile, error) {
+	if p.Name == newName {
+		return nil, ErrExists
+	}
+	np := *p
+	np.Name = newName
+	np.Usage = 2
+	if p.Rules != nil {
+		np.Rules = &OnuVlanRuleList{}
+		for _, r := range p.Rules.Entry {
+			nr := *r
+			nr.Name = newName
+			np.Rules.Entry = append(np.Rules.Entry, &nr)
+		}
+	}
+	return &np, nil
+}
+
 var OnuVlanProfileHeaders = []string{
 	"Name",
 	"DsMode",
@@ -0,0 +1,441 @@
+package goPon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// DefaultOpticalInterval is how often an OpticalMonitor reads the Onu info table unless changed
+	DefaultOpticalInterval = 5 * time.Minute
+	// DefaultOpticalHistory is the number of readings an OpticalMonitor keeps per Onu unless changed
+	DefaultOpticalHistory = 288
+	// DefaultBaselineSamples is the number of first readings averaged into the baseline of an Onu unless changed
+	DefaultBaselineSamples = 3
+	// DefaultDropDb is the loss against the baseline at which a link is reported degraded unless changed
+	DefaultDropDb = 3.0
+	// DefaultOpticalClass is the class of the ports not listed in OpticalMonitor.PortClass unless changed
+	DefaultOpticalClass = "B+"
+)
+
+// levels of an optical reading
+const (
+	LevelOk       = "ok"
+	LevelWarning  = "warning"
+	LevelCritical = "critical"
+)
+
+// kinds of OpticalEvent
+const (
+	OpticalWarning   = "warning"   // a reading crossed into the warning level
+	OpticalCritical  = "critical"  // a reading crossed into the critical level
+	OpticalCleared   = "cleared"   // a reading returned to the ok level
+	OpticalDegraded  = "degraded"  // the receive power dropped DropDb or more against the baseline
+	OpticalRecovered = "recovered" // the receive power came back within DropDb of the baseline
+	OpticalDown      = "down"      // a monitored Onu went down, OpticalCleared follows for MetricLink when it is back up
+)
+
+// metrics of an OpticalEvent
+const (
+	MetricRx    = "rx"     // optical power received by the Onu, dBm
+	MetricOltRx = "olt rx" // optical power of the Onu received by the Olt, dBm
+	MetricTemp  = "temp"   // temperature of the Onu optics, degrees Celsius
+	MetricLink  = "link"   // operational state of the Onu, without a value
+)
+
+// OpticalThresholds are the levels of a class of optics. Receive powers at or below Warn and Crit are weak,
+// receive powers at or above Overload saturate the receiver and are critical
+type OpticalThresholds struct {
+	RxWarn, RxCrit, RxOverload          float64
+	OltRxWarn, OltRxCrit, OltRxOverload float64
+	TempWarn, TempCrit                  float64
+}
+
+// DefaultOpticalClasses returns the thresholds of the GPON optics classes of ITU-T G.984.2,
+// warning 3 dB above the receiver sensitivity
+func DefaultOpticalClasses() map[string]*OpticalThresholds {
+	return map[string]*OpticalThresholds{
+		"B+": {
+			RxWarn: -24, RxCrit: -27, RxOverload: -8,
+			OltRxWarn: -25, OltRxCrit: -28, OltRxOverload: -8,
+			TempWarn: 70, TempCrit: 85,
+		},
+		"C+": {
+			RxWarn: -27, RxCrit: -30, RxOverload: -8,
+			OltRxWarn: -29, OltRxCrit: -32, OltRxOverload: -12,
+			TempWarn: 70, TempCrit: 85,
+		},
+	}
+}
+
+// OpticalSample is one reading of the optics of an Onu, converted from the raw values of its OnuInfo
+type OpticalSample struct {
+	Time       time.Time
+	Interface  string
+	RxPower    float64 // dBm
+	TxPower    float64 // dBm
+	OltRxPower float64 // dBm
+	Temp       float64 // degrees Celsius
+}
+
+// NewOpticalSample converts the readings of an OnuInfo
+func NewOpticalSample(info *OnuInfo) *OpticalSample {
+	return &OpticalSample{
+		Time:       time.Now(),
+		Interface:  info.IfName,
+		RxPower:    info.RxPowerDbm(),
+		TxPower:    info.TxPowerDbm(),
+		OltRxPower: info.OltRxPowerDbm(),
+		Temp:       info.TempCelsius(),
+	}
+}
+
+// OpticalEvent is raised by an OpticalMonitor when a reading of an Onu changes level or drifts from its baseline
+type OpticalEvent struct {
+	Time         time.Time
+	Kind         string
+	SerialNumber string
+	Interface    string
+	Metric       string
+	Value        float64
+	Limit        float64 // threshold crossed, or the baseline for OpticalDegraded and OpticalRecovered
+}
+
+func (e *OpticalEvent) String() string {
+	if e.Metric == MetricLink {
+		return fmt.Sprintf("%s %s %s on %s: %s", e.Time.Format(time.RFC3339), e.Kind, e.SerialNumber, e.Interface, e.Metric)
+	}
+	switch e.Kind {
+	case OpticalDegraded, OpticalRecovered:
+		return fmt.Sprintf("%s %s %s on %s: %s %.2f, %.2f against baseline %.2f", e.Time.Format(time.RFC3339), e.Kind, e.SerialNumber, e.Interface, e.Metric, e.Value, e.Value-e.Limit, e.Limit)
+	}
+	return fmt.Sprintf("%s %s %s on %s: %s %.2f (limit %.2f)", e.Time.Format(time.RFC3339), e.Kind, e.SerialNumber, e.Interface, e.Metric, e.Value, e.Limit)
+}
+
+// opticalLink is what an OpticalMonitor remembers of one Onu
+type opticalLink struct {
+	class         string
+	history       []*OpticalSample
+	baselineRx    float64
+	baselineOltRx float64
+	baselineN     int               // readings averaged into the baseline so far
+	levels        map[string]string // metric to its current level
+	degraded      map[string]bool   // metric to whether it is reported degraded
+	down          bool              // reported down and not seen up since
+}
+
+// OpticalMonitor reads the Onu info table of an Olt, checks the optics of every Onu that is up against the thresholds
+// of its class and its own baseline, and raises an event each time a reading crosses a level or a monitored Onu goes down
+type OpticalMonitor struct {
+	Interval        time.Duration                 // time between reads of the info table
+	Port            string                        // only the Onu of this Olt interface (0/x) when set
+	Classes         map[string]*OpticalThresholds // thresholds by class name, DefaultOpticalClasses unless changed
+	PortClass       map[string]string             // class of the optics of an Olt interface (0/x)
+	DefaultClass    string                        // class of the Olt interfaces not in PortClass
+	History         int                           // readings kept per Onu
+	BaselineSamples int                           // first readings of an Onu averaged into its baseline
+	DropDb          float64                       // loss against the baseline reported as degraded
+	OnEvent         func(*OpticalEvent)           // called with each event as it is raised, if set; every event is also logged
+	olt             *LumiaOlt
+	mu              sync.Mutex
+	links           map[string]*opticalLink // by Serial Number
+}
+
+// NewOpticalMonitor returns an OpticalMonitor for l.Host with the default thresholds
+func (l *LumiaOlt) NewOpticalMonitor() *OpticalMonitor {
+	return &OpticalMonitor{
+		Interval:        DefaultOpticalInterval,
+		Classes:         DefaultOpticalClasses(),
+		PortClass:       make(map[string]string),
+		DefaultClass:    DefaultOpticalClass,
+		History:         DefaultOpticalHistory,
+		BaselineSamples: DefaultBaselineSamples,
+		DropDb:          DefaultDropDb,
+		olt:             l,
+		links:           make(map[string]*opticalLink),
+	}
+}
+
+// Run reads the info table every Interval until ctx is done. A failed read is logged and tried again at the next interval
+func (m *OpticalMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		_, err := m.Poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("opticalMonitor: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the info table once and checks it, returning the events raised. The read is bounded by ctx
+func (m *OpticalMonitor) Poll(ctx context.Context) ([]*OpticalEvent, error) {
+	var oil *OnuInfoList
+	var err error
+	if m.Port != "" {
+		// trailing '/' so 0/1 does not match 0/10 and above
+		oil, err = m.olt.GetOnuInfoListPerPortCtx(ctx, m.Port+"/")
+	} else {
+		oil, err = m.olt.GetOnuInfoListCtx(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.Check(oil), nil
+}
+
+// Check adds a reading for every Onu of the list that is up and returns the events raised.
+// A monitored Onu that goes down raises OpticalDown, keeping its history for when it returns
+func (m *OpticalMonitor) Check(oil *OnuInfoList) []*OpticalEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var events []*OpticalEvent
+	for _, info := range oil.Entry {
+		if info.SerialNumber == "" {
+			continue
+		}
+		if !info.IsUp() {
+			events = append(events, m.down(info.SerialNumber, info.IfName)...)
+			continue
+		}
+		events = append(events, m.check(info.SerialNumber, NewOpticalSample(info))...)
+	}
+	return events
+}
+
+// down raises OpticalDown the first time a monitored Onu is read down. The caller holds m.mu
+func (m *OpticalMonitor) down(sn, intf string) []*OpticalEvent {
+	link, ok := m.links[sn]
+	if !ok || link.down {
+		return nil
+	}
+	link.down = true
+	return []*OpticalEvent{m.raise(&OpticalEvent{Kind: OpticalDown, SerialNumber: sn, Interface: intf, Metric: MetricLink})}
+}
+
+// check records the sample of the Onu and compares it with the thresholds of its class and its baseline.
+// The caller holds m.mu
+func (m *OpticalMonitor) check(sn string, s *OpticalSample) []*OpticalEvent {
+	var events []*OpticalEvent
+	link, ok := m.links[sn]
+	if ok && link.down {
+		link.down = false
+		events = append(events, m.raise(&OpticalEvent{Kind: OpticalCleared, SerialNumber: sn, Interface: s.Interface, Metric: MetricLink}))
+	}
+	if !ok || link.latest().Interface != s.Interface {
+		// a new Onu, or one moved onto another fibre, starts a new baseline
+		link = &opticalLink{levels: make(map[string]string), degraded: make(map[string]bool)}
+		m.links[sn] = link
+	}
+	link.class = m.classOf(s.Interface)
+	link.history = append(link.history, s)
+	if m.History > 0 && len(link.history) > m.History {
+		link.history = link.history[len(link.history)-m.History:]
+	}
+	if t := m.Classes[link.class]; t != nil {
+		level, limit := weakLevel(s.RxPower, t.RxWarn, t.RxCrit, t.RxOverload)
+		events = append(events, m.level(sn, link, s, MetricRx, s.RxPower, level, limit)...)
+		level, limit = weakLevel(s.OltRxPower, t.OltRxWarn, t.OltRxCrit, t.OltRxOverload)
+		events = append(events, m.level(sn, link, s, MetricOltRx, s.OltRxPower, level, limit)...)
+		level, limit = hotLevel(s.Temp, t.TempWarn, t.TempCrit)
+		events = append(events, m.level(sn, link, s, MetricTemp, s.Temp, level, limit)...)
+	}
+	if link.baselineN < m.BaselineSamples {
+		// running average of the first readings
+		n := float64(link.baselineN)
+		link.baselineRx = (link.baselineRx*n + s.RxPower) / (n + 1)
+		link.baselineOltRx = (link.baselineOltRx*n + s.OltRxPower) / (n + 1)
+		link.baselineN++
+		return events
+	}
+	events = append(events, m.drift(sn, link, s, MetricRx, s.RxPower, link.baselineRx)...)
+	events = append(events, m.drift(sn, link, s, MetricOltRx, s.OltRxPower, link.baselineOltRx)...)
+	return events
+}
+
+// level raises an event when the level of the metric changed since the last reading. The caller holds m.mu
+func (m *OpticalMonitor) level(sn string, link *opticalLink, s *OpticalSample, metric string, value float64, level string, limit float64) []*OpticalEvent {
+	prev := link.levels[metric]
+	if prev == "" {
+		prev = LevelOk
+	}
+	link.levels[metric] = level
+	if level == prev {
+		return nil
+	}
+	kind := OpticalCleared
+	switch level {
+	case LevelWarning:
+		kind = OpticalWarning
+	case LevelCritical:
+		kind = OpticalCritical
+	}
+	return []*OpticalEvent{m.raise(&OpticalEvent{Kind: kind, SerialNumber: sn, Interface: s.Interface, Metric: metric, Value: value, Limit: limit})}
+}
+
+// drift raises an event when the metric drops DropDb or more below its baseline, and when it comes back.
+// The caller holds m.mu
+func (m *OpticalMonitor) drift(sn string, link *opticalLink, s *OpticalSample, metric string, value, baseline float64) []*OpticalEvent {
+	if m.DropDb <= 0 {
+		return nil
+	}
+	degraded := baseline-value >= m.DropDb
+	if degraded == link.degraded[metric] {
+		return nil
+	}
+	link.degraded[metric] = degraded
+	kind := OpticalRecovered
+	if degraded {
+		kind = OpticalDegraded
+	}
+	return []*OpticalEvent{m.raise(&OpticalEvent{Kind: kind, SerialNumber: sn, Interface: s.Interface, Metric: metric, Value: value, Limit: baseline})}
+}
+
+// weakLevel returns the level of a receive power and the threshold it is at, or the warning threshold when ok
+func weakLevel(v, warn, crit, overload float64) (string, float64) {
+	switch {
+	case v <= crit:
+		return LevelCritical, crit
+	case overload != 0 && v >= overload:
+		return LevelCritical, overload
+	case v <= warn:
+		return LevelWarning, warn
+	}
+	return LevelOk, warn
+}
+
+// hotLevel returns the level of a temperature and the threshold it is at, or the warning threshold when ok
+func hotLevel(v, warn, crit float64) (string, float64) {
+	switch {
+	case v >= crit:
+		return LevelCritical, crit
+	case v >= warn:
+		return LevelWarning, warn
+	}
+	return LevelOk, warn
+}
+
+// classOf returns the class of the optics of the Olt interface an Onu interface (0/x/y) is on
+func (m *OpticalMonitor) classOf(intf string) string {
+	for port, class := range m.PortClass {
+		if strings.HasPrefix(intf, port+"/") {
+			return class
+		}
+	}
+	return m.DefaultClass
+}
+
+// raise stamps, logs and delivers an event
+func (m *OpticalMonitor) raise(ev *OpticalEvent) *OpticalEvent {
+	ev.Time = time.Now()
+	log.Printf("opticalMonitor: %v\n", ev)
+	if m.OnEvent != nil {
+		m.OnEvent(ev)
+	}
+	return ev
+}
+
+// GetHistory returns the readings kept for the Serial Number, oldest first
+func (m *OpticalMonitor) GetHistory(sn string) []*OpticalSample {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link, ok := m.links[sn]
+	if !ok {
+		return nil
+	}
+	return append([]*OpticalSample(nil), link.history...)
+}
+
+// ResetBaseline starts a new baseline for the Serial Number from its next readings, such as after a connector was cleaned
+func (m *OpticalMonitor) ResetBaseline(sn string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if link, ok := m.links[sn]; ok {
+		link.baselineRx, link.baselineOltRx, link.baselineN = 0, 0, 0
+		link.degraded = make(map[string]bool)
+	}
+}
+
+var OpticalStatusHeaders = []string{
+	"Interface",
+	"Serial Number",
+	"Class",
+	"Rx dBm",
+	"Baseline",
+	"OLT Rx dBm",
+	"Baseline",
+	"Tx dBm",
+	"Temp C",
+	"Level",
+}
+
+// latest returns the last reading of the link
+func (link *opticalLink) latest() *OpticalSample {
+	return link.history[len(link.history)-1]
+}
+
+// worstLevel returns the most severe level of the link
+func (link *opticalLink) worstLevel() string {
+	worst := LevelOk
+	for _, l := range link.levels {
+		if l == LevelCritical {
+			return l
+		}
+		if l == LevelWarning {
+			worst = l
+		}
+	}
+	return worst
+}
+
+// Tabwrite displays the latest reading of every Onu with its baseline and level in organized columns
+func (m *OpticalMonitor) Tabwrite() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sns := make([]string, 0, len(m.links))
+	for sn := range m.links {
+		sns = append(sns, sn)
+	}
+	sort.Slice(sns, func(i, j int) bool {
+		return m.links[sns[i]].latest().Interface < m.links[sns[j]].latest().Interface
+	})
+	fmt.Println("|| Optical Status ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range OpticalStatusHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range OpticalStatusHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, sn := range sns {
+		link := m.links[sn]
+		s := link.latest()
+		level := link.worstLevel()
+		if link.degraded[MetricRx] || link.degraded[MetricOltRx] {
+			level += ", " + OpticalDegraded
+		}
+		if link.down {
+			level += ", " + OpticalDown
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%.0f\t%v\t\n", s.Interface, sn, link.class, s.RxPower, link.baselineRx, s.OltRxPower, link.baselineOltRx, s.TxPower, s.Temp, level)
+	}
+	for _, v := range OpticalStatusHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
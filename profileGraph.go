@@ -0,0 +1,349 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// subProfileRefs maps each reference field of a ServiceProfile to the table of the sub-profile it names
+var subProfileRefs = []struct {
+	field string
+	table string
+}{
+	{"FlowProfileName", flowProfiles},
+	{"MulticastProfileName", igmpProfiles},
+	{"VlanProfileName", vlanProfiles},
+	{"L2cpProfileName", l2cpProfiles},
+	{"SecurityProfileName", securityProfiles},
+	{"OnuFlowProfileName", onuFlowProfiles},
+	{"OnuVlanProfileName", onuVlanProfiles},
+	{"OnuMulticastProfileName", onuIgmpProfiles},
+	{"OnuTcontProfileName", onuTcontProfiles},
+}
+
+// ProfileNode identifies an entry of a table by its key
+type ProfileNode struct {
+	Table string `json:"table"`
+	Name  string `json:"name"`
+}
+
+// ProfileRef is a reference by name from one entry to another
+type ProfileRef struct {
+	From  ProfileNode `json:"from"`
+	Field string      `json:"field"`
+	To    ProfileNode `json:"to"`
+}
+
+// ProfileRefList is an ordered list of references
+type ProfileRefList struct {
+	Entry []*ProfileRef
+}
+
+// ProfileGraph holds the references between the entries of a tree: each Service Profile to its sub-profiles,
+// and each Onu Service Profile binding to its Service Profile and Onu Config. It is safe for concurrent use
+type ProfileGraph struct {
+	mu     sync.Mutex
+	exists map[string]map[string]bool
+	usage  map[string]map[string]int
+	refs   []*ProfileRef
+}
+
+// NewProfileGraph indexes the entries and references of a complete tree, such as the Data of a Snapshot
+func NewProfileGraph(im *IskratelMsan) *ProfileGraph {
+	g := &ProfileGraph{
+		exists: make(map[string]map[string]bool),
+		usage:  make(map[string]map[string]int),
+	}
+	tables := []string{serviceProfiles, onuConfig, onuProfiles}
+	for _, r := range subProfileRefs {
+		tables = append(tables, r.table)
+	}
+	for _, table := range tables {
+		g.exists[table] = make(map[string]bool)
+		g.usage[table] = make(map[string]int)
+		for key, e := range keyEntries(im.tableEntries(table), tableKeys[table]) {
+			g.exists[table][key] = true
+			if u := e.FieldByName("Usage"); u.IsValid() {
+				g.usage[table][key] = int(u.Int())
+			}
+		}
+	}
+	for _, sp := range im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry {
+		v := reflect.ValueOf(sp)
+		for _, r := range subProfileRefs {
+			name := v.FieldByName(r.field).String()
+			if name == "" {
+				continue
+			}
+			g.refs = append(g.refs, &ProfileRef{
+				From:  ProfileNode{serviceProfiles, sp.Name},
+				Field: r.field,
+				To:    ProfileNode{r.table, name},
+			})
+		}
+	}
+	for _, op := range im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry {
+		from := ProfileNode{onuProfiles, op.IfName + "," + op.ServiceProfileName}
+		g.refs = append(g.refs,
+			&ProfileRef{From: from, Field: "ServiceProfileName", To: ProfileNode{serviceProfiles, op.ServiceProfileName}},
+			&ProfileRef{From: from, Field: "IfName", To: ProfileNode{onuConfig, op.IfName}},
+		)
+	}
+	return g
+}
+
+// GetProfileGraph retrieves the complete tree of the OLT and builds its ProfileGraph
+func (l *LumiaOlt) GetProfileGraph() (*ProfileGraph, error) {
+	im, err := l.GetAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	return NewProfileGraph(im), nil
+}
+
+// Dangling returns the references to entries that do not exist
+func (g *ProfileGraph) Dangling() *ProfileRefList {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	list := &ProfileRefList{}
+	for _, r := range g.refs {
+		if !g.exists[r.To.Table][r.To.Name] {
+			list.Entry = append(list.Entry, r)
+		}
+	}
+	return list
+}
+
+// Orphans returns the sub-profiles that no Service Profile references and the OLT does not report as used
+func (g *ProfileGraph) Orphans() []ProfileNode {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	referenced := make(map[ProfileNode]bool)
+	for _, r := range g.refs {
+		referenced[r.To] = true
+	}
+	var list []ProfileNode
+	for _, r := range subProfileRefs {
+		for name := range g.exists[r.table] {
+			n := ProfileNode{r.table, name}
+			if !referenced[n] && g.usage[r.table][name] != 1 {
+				list = append(list, n)
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Table != list[j].Table {
+			return list[i].Table < list[j].Table
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// DependentsOf answers what breaks if the entry is deleted: every reference to it, followed by the references
+// to the entries that break in turn, such as the Onu bindings of a Service Profile that uses a deleted sub-profile
+func (g *ProfileGraph) DependentsOf(table, name string) *ProfileRefList {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	list := &ProfileRefList{}
+	seen := map[ProfileNode]bool{{table, name}: true}
+	queue := []ProfileNode{{table, name}}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, r := range g.refs {
+			if r.To != n {
+				continue
+			}
+			list.Entry = append(list.Entry, r)
+			if !seen[r.From] {
+				seen[r.From] = true
+				queue = append(queue, r.From)
+			}
+		}
+	}
+	return list
+}
+
+// Remove drops an entry and the references it makes, so the graph follows a successful delete
+func (g *ProfileGraph) Remove(table, name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.exists[table], name)
+	kept := g.refs[:0]
+	for _, r := range g.refs {
+		if r.From != (ProfileNode{table, name}) {
+			kept = append(kept, r)
+		}
+	}
+	g.refs = kept
+}
+
+// put records an entry and the references it makes through the fields of refs, replacing any earlier reference
+// of those fields, so the graph follows a successful Post or Patch. A reference to a node without a Name is dropped
+func (g *ProfileGraph) put(table, name string, refs map[string]ProfileNode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.exists[table] == nil {
+		g.exists[table] = make(map[string]bool)
+	}
+	g.exists[table][name] = true
+	from := ProfileNode{table, name}
+	kept := g.refs[:0]
+	for _, r := range g.refs {
+		if _, ok := refs[r.Field]; !ok || r.From != from {
+			kept = append(kept, r)
+		}
+	}
+	g.refs = kept
+	var fields []string
+	for f := range refs {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		if to := refs[f]; to.Name != "" {
+			g.refs = append(g.refs, &ProfileRef{From: from, Field: f, To: to})
+		}
+	}
+}
+
+// DependencyError is returned by the Delete methods when l.Graph shows the entry is still referenced
+type DependencyError struct {
+	Node       ProfileNode
+	Dependents *ProfileRefList
+}
+
+func (e *DependencyError) Error() string {
+	var from []string
+	for _, r := range e.Dependents.Entry {
+		if r.To == e.Node {
+			from = append(from, r.From.Name)
+		}
+	}
+	return fmt.Sprintf("%s %s is referenced by %s (%d dependents in total)", e.Node.Table, e.Node.Name, strings.Join(from, ", "), len(e.Dependents.Entry))
+}
+
+// Is allows errors.Is to match a DependencyError as ErrInUse
+func (e *DependencyError) Is(target error) bool {
+	return target == ErrInUse
+}
+
+// checkDependents returns a DependencyError if l.Graph is set and shows the entry is referenced
+func (l *LumiaOlt) checkDependents(table, name string) error {
+	if l.Graph == nil {
+		return nil
+	}
+	deps := l.Graph.DependentsOf(table, name)
+	if len(deps.Entry) > 0 {
+		return &DependencyError{Node: ProfileNode{table, name}, Dependents: deps}
+	}
+	return nil
+}
+
+// deleteChecked performs the Delete request of a profile after consulting l.Graph, removing it from the graph on success
+func (l *LumiaOlt) deleteChecked(ctx context.Context, table, name string) error {
+	err := l.checkDependents(table, name)
+	if err != nil {
+		return err
+	}
+	err = l.Client.Delete(ctx, table, name)
+	if err != nil {
+		return err
+	}
+	if l.Graph != nil {
+		l.Graph.Remove(table, name)
+	}
+	return nil
+}
+
+// postRecorded performs the Post request of a profile, adding it to l.Graph on success along with the
+// sub-profiles a Service Profile references
+func (l *LumiaOlt) postRecorded(ctx context.Context, table, name string, data []byte) error {
+	err := l.Client.Post(ctx, table, name, data)
+	if err != nil {
+		return err
+	}
+	if l.Graph != nil {
+		var refs map[string]ProfileNode
+		if table == serviceProfiles {
+			refs = serviceProfileRefs(data, false)
+		}
+		l.Graph.put(table, name, refs)
+	}
+	return nil
+}
+
+// patchRecorded performs the Patch request of a profile, replacing in l.Graph on success the references
+// a Service Profile makes through the fields present in data
+func (l *LumiaOlt) patchRecorded(ctx context.Context, table, name string, data []byte) error {
+	err := l.Client.Patch(ctx, table, name, data)
+	if err != nil {
+		return err
+	}
+	if l.Graph != nil {
+		var refs map[string]ProfileNode
+		if table == serviceProfiles {
+			refs = serviceProfileRefs(data, true)
+		}
+		l.Graph.put(table, name, refs)
+	}
+	return nil
+}
+
+// serviceProfileRefs reads the sub-profile references of serialized ServiceProfile fields. A field missing from data
+// references nothing, or is left out of the result when onlyPresent is set as a Patch leaves it unchanged
+func serviceProfileRefs(data []byte, onlyPresent bool) map[string]ProfileNode {
+	fields := make(map[string]interface{})
+	// data was accepted by the OLT, an undecodable body leaves every reference unset
+	_ = json.Unmarshal(data, &fields)
+	t := reflect.TypeOf(ServiceProfile{})
+	refs := make(map[string]ProfileNode)
+	for _, r := range subProfileRefs {
+		f, _ := t.FieldByName(r.field)
+		v, ok := fields[jsonName(f)]
+		if !ok && onlyPresent {
+			continue
+		}
+		name, _ := v.(string)
+		refs[r.field] = ProfileNode{r.table, name}
+	}
+	return refs
+}
+
+var ProfileRefHeaders = []string{
+	"From Table",
+	"From",
+	"Field",
+	"To Table",
+	"To",
+}
+
+// Tabwrite displays the references in organized columns
+func (list *ProfileRefList) Tabwrite() {
+	fmt.Println("|| Profile References ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range ProfileRefHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range ProfileRefHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, r := range list.Entry {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t\n", r.From.Table, r.From.Name, r.Field, r.To.Table, r.To.Name)
+	}
+	for _, v := range ProfileRefHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
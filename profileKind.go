@@ -0,0 +1,55 @@
+package goPon
+
+// ProfileKind names a table of profiles for the methods that work across all of them
+type ProfileKind string
+
+// kinds of profile, each the Restconf table holding it
+const (
+	KindVlanProfile         ProfileKind = vlanProfiles
+	KindFlowProfile         ProfileKind = flowProfiles
+	KindSecurityProfile     ProfileKind = securityProfiles
+	KindMulticastProfile    ProfileKind = igmpProfiles
+	KindL2cpProfile         ProfileKind = l2cpProfiles
+	KindOnuFlowProfile      ProfileKind = onuFlowProfiles
+	KindOnuTcontProfile     ProfileKind = onuTcontProfiles
+	KindOnuVlanProfile      ProfileKind = onuVlanProfiles
+	KindOnuMulticastProfile ProfileKind = onuIgmpProfiles
+	KindServiceProfile      ProfileKind = serviceProfiles
+)
+
+// IsSubProfile reports whether profiles of the kind are referenced by Service Profiles
+func (k ProfileKind) IsSubProfile() bool {
+	for _, r := range subProfileRefs {
+		if r.table == string(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyProfile calls the Copy method of a profile
+func copyProfile(p interface{}, newName string) (interface{}, error) {
+	switch v := p.(type) {
+	case *VlanProfile:
+		return v.Copy(newName)
+	case *FlowProfile:
+		return v.Copy(newName)
+	case *SecurityProfile:
+		return v.Copy(newName)
+	case *IgmpProfile:
+		return v.Copy(newName)
+	case *L2cpProfile:
+		return v.Copy(newName)
+	case *OnuFlowProfile:
+		return v.Copy(newName)
+	case *OnuTcontProfile:
+		return v.Copy(newName)
+	case *OnuVlanProfile:
+		return v.Copy(newName)
+	case *OnuIgmpProfile:
+		return v.Copy(newName)
+	case *ServiceProfile:
+		return v.Copy(newName)
+	}
+	return nil, ErrNotStruct
+}
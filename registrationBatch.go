@@ -0,0 +1,276 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// kinds of RegistrationResult
+const (
+	RegistrationDone       = "registered"
+	RegistrationFailed     = "failed"
+	RegistrationRolledBack = "rolled back"
+	RegistrationSkipped    = "skipped" // not attempted after another Onu failed with AllOrNothing set
+)
+
+// RegistrationResult is the outcome of registering a single Onu in a RegistrationBatch
+type RegistrationResult struct {
+	SerialNumber string   `json:"serialNumber"`
+	Interface    string   `json:"interface"`
+	Services     []string `json:"services"` // Service Profiles bound by the batch
+	Status       string   `json:"status"`
+	Err          error    `json:"-"` // why the Onu failed, or failed to roll back
+}
+
+// RegistrationReport lists the outcome of every Onu of a RegistrationBatch in the order they were added
+type RegistrationReport struct {
+	Entry []*RegistrationResult
+}
+
+// registration is an Onu waiting in a RegistrationBatch
+type registration struct {
+	ocfg     *OnuConfig
+	services []string
+}
+
+// RegistrationBatch authorizes a set of Onu and binds their Service Profiles, recording every change it makes.
+// If any step for an Onu fails, that Onu's changes are undone so it is left unregistered rather than partially
+// turned up. With AllOrNothing set, a failure stops the Onu not yet started and undoes every Onu of the batch
+type RegistrationBatch struct {
+	AllOrNothing bool
+	olt          *LumiaOlt
+	queue        []*registration
+}
+
+// NewRegistrationBatch returns an empty batch for l.Host
+func (l *LumiaOlt) NewRegistrationBatch() *RegistrationBatch {
+	return &RegistrationBatch{olt: l}
+}
+
+// Add queues an Onu Config to be authorized with AuthorizeOnu, followed by each Service Profile
+func (b *RegistrationBatch) Add(ocfg *OnuConfig, services []string) {
+	b.queue = append(b.queue, &registration{ocfg: ocfg, services: services})
+}
+
+// Run registers the queued Onu, l.Concurrency at a time. An interface already configured with the same Serial Number and
+// bindings that already exist are left as they are, so a batch can be run again after fixing a failure.
+// The report holds the outcome of every Onu in the order they were added; the failures are also returned as a *BulkError
+func (b *RegistrationBatch) Run() (*RegistrationReport, error) {
+	return b.RunCtx(context.Background())
+}
+
+// RunCtx is Run with its requests bounded by ctx. The changes of a failed Onu are undone even once ctx is done
+func (b *RegistrationBatch) RunCtx(ctx context.Context) (*RegistrationReport, error) {
+	l := b.olt
+	current, err := l.getOnuTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := &registrationState{
+		configured: make(map[string]string),
+		bound:      make(map[string]bool),
+	}
+	for _, c := range current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuCfgTable.MsanOnuCfgEntry {
+		if c.SerialNumber != "" || c.Password != "" {
+			state.configured[c.IfName] = registerKey(c.SerialNumber, c.Password)
+		}
+	}
+	for _, op := range current.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServicePortProfileTable.MsanServicePortProfileEntry {
+		state.bound[op.IfName+","+op.ServiceProfileName] = true
+	}
+
+	report := &RegistrationReport{}
+	items := make([]string, len(b.queue))
+	for i, reg := range b.queue {
+		items[i] = reg.ocfg.authKey()
+		report.Entry = append(report.Entry, &RegistrationResult{SerialNumber: reg.ocfg.SerialNumber, Interface: reg.ocfg.IfName, Status: RegistrationSkipped})
+	}
+	undone := make([][]func() error, len(b.queue))
+	err = l.runBulk(ctx, items, func(i int, _ string) error {
+		result := report.Entry[i]
+		if b.AllOrNothing && state.hasFailed() {
+			// left for the rollback below to find untouched
+			return nil
+		}
+		undo, err := l.register(ctx, b.queue[i], state, result)
+		if err != nil {
+			result.Status = RegistrationFailed
+			result.Err = l.rollback(err, undo)
+			result.Services = nil
+			return err
+		}
+		result.Status = RegistrationDone
+		undone[i] = undo
+		return nil
+	})
+	if err != nil && b.AllOrNothing {
+		// undo the Onu already registered, latest first
+		for i := len(undone) - 1; i >= 0; i-- {
+			if len(undone[i]) == 0 {
+				// nothing was changed for this Onu by the batch
+				continue
+			}
+			prev := report.Entry[i]
+			prev.Status = RegistrationRolledBack
+			prev.Services = nil
+			if rerr := l.rollback(err, undone[i]); rerr != err {
+				prev.Err = rerr
+			}
+		}
+	}
+	return report, err
+}
+
+// registrationState is what a running RegistrationBatch knows of the Onu Config and binding tables, shared by its workers
+type registrationState struct {
+	mu         sync.Mutex
+	configured map[string]string // interface to the Serial Number, or password when authorized by password only
+	bound      map[string]bool   // interface,Service Profile
+	failed     bool
+}
+
+// claim marks the interface as configured for the Onu, reporting false if an earlier run already did
+func (s *registrationState) claim(intf, sn string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.configured[intf]
+	switch {
+	case ok && cur == sn:
+		return false, nil
+	case ok:
+		return false, fmt.Errorf("%w: %s is configured with %s", ErrExists, intf, cur)
+	}
+	s.configured[intf] = sn
+	return true, nil
+}
+
+// isBound reports whether the binding existed before the batch ran
+func (s *registrationState) isBound(intf, sp string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bound[intf+","+sp]
+}
+
+// fail records a failed Onu, freeing its interface again if the batch had claimed it
+func (s *registrationState) fail(intf string, claimed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	if claimed {
+		delete(s.configured, intf)
+	}
+}
+
+func (s *registrationState) hasFailed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failed
+}
+
+// register authorizes one Onu and binds its services, returning the steps that undo what was changed.
+// On failure the state is told, as the caller is going to run those steps
+func (l *LumiaOlt) register(ctx context.Context, reg *registration, state *registrationState, result *RegistrationResult) (undo []func() error, err error) {
+	intf := reg.ocfg.IfName
+	// the interface is claimed before the request, so a second entry for it in the batch is refused rather than overwriting this one
+	claimed, err := state.claim(intf, reg.ocfg.authKey())
+	if err != nil {
+		state.fail(intf, false)
+		return undo, err
+	}
+	defer func() {
+		if err != nil {
+			state.fail(intf, claimed)
+		}
+	}()
+	if claimed {
+		err = l.AuthorizeOnuCtx(ctx, reg.ocfg)
+		if err != nil {
+			return undo, err
+		}
+		undo = append(undo, func() error { return l.AuthorizeOnuOverride(GenerateBlankConfig(intf)) })
+	}
+	for _, sp := range reg.services {
+		if state.isBound(intf, sp) {
+			continue
+		}
+		err = l.PostOnuProfileCtx(ctx, NewOnuProfile(intf, sp))
+		if err != nil {
+			return undo, fmt.Errorf("%s: %w", sp, err)
+		}
+		spName := sp
+		undo = append(undo, func() error { return l.RemoveOnuProfileUsage(intf, spName) })
+		result.Services = append(result.Services, sp)
+	}
+	return undo, nil
+}
+
+// getOnuTables retrieves the Onu Config and Service Profile binding tables without changing Current
+func (l *LumiaOlt) getOnuTables(ctx context.Context) (*IskratelMsan, error) {
+	im := NewIskratelMsan()
+	for _, ep := range []string{onuConfig, onuProfiles} {
+		rawJson, err := l.Client.Get(ctx, ep)
+		if err != nil {
+			return nil, err
+		}
+		fresh := NewIskratelMsan()
+		err = json.Unmarshal(rawJson, fresh)
+		if err != nil {
+			return nil, err
+		}
+		err = im.setTable(ep, fresh)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return im, nil
+}
+
+// Failed returns the Onu that were not registered
+func (r *RegistrationReport) Failed() []*RegistrationResult {
+	var list []*RegistrationResult
+	for _, e := range r.Entry {
+		if e.Status != RegistrationDone {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+var RegistrationReportHeaders = []string{
+	"Serial Number",
+	"Interface",
+	"Services",
+	"Status",
+	"Error",
+}
+
+// Tabwrite displays the outcome of each Onu in organized columns
+func (r *RegistrationReport) Tabwrite() {
+	fmt.Println("|| Registration Report ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range RegistrationReportHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range RegistrationReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, e := range r.Entry {
+		var errString string
+		if e.Err != nil {
+			errString = e.Err.Error()
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t\n", e.SerialNumber, e.Interface, strings.Join(e.Services, ", "), e.Status, errString)
+	}
+	for _, v := range RegistrationReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
@@ -0,0 +1,191 @@
+package goPon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// RegistryStoreVersion is the format version written by a FileRegistryStore
+const RegistryStoreVersion = 1
+
+// states of an OnuRegister
+const (
+	StatePreProvisioned = "pre-provisioned" // on the Authorized List, not configured on an interface yet
+	StateActive         = "active"          // configured on its Interface
+	StateSuspended      = "suspended"       // configured on its Interface but out of service
+	StateRemoved        = "removed"         // deauthorized or gone from the Olt, kept for its history only
+)
+
+// RegistryStore keeps the Registration index of an Olt between runs, see OpenRegistryStore
+type RegistryStore interface {
+	Load() ([]*OnuRegister, error)
+	Save(list []*OnuRegister) error
+}
+
+// FileRegistryStore is a RegistryStore kept as an indented Json file
+type FileRegistryStore struct {
+	Path string
+	mu   sync.Mutex
+}
+
+type registryFile struct {
+	Version   int            `json:"version"`
+	Timestamp time.Time      `json:"timestamp"`
+	Entry     []*OnuRegister `json:"entry"`
+}
+
+// NewFileRegistryStore returns a FileRegistryStore for the file at path, which is created by the first Save
+func NewFileRegistryStore(path string) *FileRegistryStore {
+	return &FileRegistryStore{Path: path}
+}
+
+// Load reads the records of the file, or none if it does not exist yet
+func (s *FileRegistryStore) Load() ([]*OnuRegister, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rf registryFile
+	err = json.Unmarshal(data, &rf)
+	if err != nil {
+		return nil, err
+	}
+	if rf.Version != RegistryStoreVersion {
+		return nil, fmt.Errorf("%w: %d", ErrNotVersion, rf.Version)
+	}
+	return rf.Entry, nil
+}
+
+// Save replaces the records of the file. The file is written beside the old one first,
+// so a failed write leaves the previous records in place
+func (s *FileRegistryStore) Save(list []*OnuRegister) error {
+	data, err := json.MarshalIndent(&registryFile{RegistryStoreVersion, time.Now(), list}, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.Path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
+
+// OpenRegistryStore loads the records of the store into the Registration index and keeps it up to date from then on:
+// UpdateOnuRegistry and the methods changing the Authorized List save it, other changes are saved with SaveRegistry.
+// An Onu already in the index keeps its Interface and Services and takes the other details of its record
+func (l *LumiaOlt) OpenRegistryStore(s RegistryStore) error {
+	list, err := s.Load()
+	if err != nil {
+		return err
+	}
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	for _, rec := range list {
+		onuReg, err := l.onuRegisterFor(rec.SerialNumber, rec.Password)
+		if err != nil || rec.State == StateRemoved {
+			l.Registration = append(l.Registration, rec)
+			continue
+		}
+		onuReg.SubscriberID = rec.SubscriberID
+		onuReg.InstallDate = rec.InstallDate
+		onuReg.Model = rec.Model
+		onuReg.Notes = rec.Notes
+		if onuReg.State == "" {
+			onuReg.State = rec.State
+		}
+	}
+	l.Store = s
+	return nil
+}
+
+// SaveRegistry writes the Registration index to the RegistryStore, if one is open
+func (l *LumiaOlt) SaveRegistry() error {
+	l.regMu.Lock()
+	defer l.regMu.Unlock()
+	return l.saveRegistry()
+}
+
+// saveRegistry is SaveRegistry for callers already holding l.regMu
+func (l *LumiaOlt) saveRegistry() error {
+	if l.Store == nil {
+		return nil
+	}
+	return l.Store.Save(l.Registration)
+}
+
+// addRegister appends an OnuRegister to the Registration index, or revives the removed record of the same Onu
+// so its details are kept. The OnuRegister in the index is returned. The caller holds l.regMu
+func (l *LumiaOlt) addRegister(onuReg *OnuRegister) *OnuRegister {
+	key := registerKey(onuReg.SerialNumber, onuReg.Password)
+	for _, r := range l.Registration {
+		if r.State == StateRemoved && registerKey(r.SerialNumber, r.Password) == key {
+			r.Interface = onuReg.Interface
+			r.Services = onuReg.Services
+			r.State = onuReg.State
+			return r
+		}
+	}
+	l.Registration = append(l.Registration, onuReg)
+	return onuReg
+}
+
+// activate marks an OnuRegister found configured on the Olt, dating its installation the first time.
+// A suspended Onu stays suspended. The caller holds l.regMu
+func (o *OnuRegister) activate() {
+	if o.State != StateSuspended {
+		o.State = StateActive
+	}
+	if o.InstallDate.IsZero() {
+		o.InstallDate = time.Now()
+	}
+}
+
+// retire keeps an OnuRegister that is out of use for its history only. The caller holds l.regMu
+func (o *OnuRegister) retire() {
+	o.State = StateRemoved
+	o.Interface = ""
+	o.Services = nil
+	o.SuspendedServices = nil
+	o.WalledGarden = ""
+}
+
+// reconcileRegistry brings the state of every OnuRegister in line with the Onu configured on the Olt.
+// Configured Onu are active. Onu that were active and are no longer configured are retired when a RegistryStore is open,
+// and dropped otherwise. The rest wait on the Authorized List as pre-provisioned. The caller holds l.regMu
+func (l *LumiaOlt) reconcileRegistry(configured map[*OnuRegister]bool) {
+	kept := l.Registration[:0]
+	for _, onuReg := range l.Registration {
+		switch {
+		case configured[onuReg]:
+			onuReg.activate()
+		case onuReg.State == StateRemoved:
+		case onuReg.State == StateActive || onuReg.State == StateSuspended:
+			if l.Store == nil {
+				continue
+			}
+			onuReg.retire()
+		default:
+			// an Onu that is not configured holds no interface, so it is free for the next registration.
+			// The services of a pre-provisioned Onu are kept, as they are the ones it is registered with
+			if onuReg.State != StatePreProvisioned {
+				onuReg.Services = nil
+			}
+			onuReg.State = StatePreProvisioned
+			onuReg.Interface = ""
+		}
+		kept = append(kept, onuReg)
+	}
+	l.Registration = kept
+}
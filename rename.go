@@ -0,0 +1,112 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RenameProfile renames a sub-profile on l.Host: the profile is copied to newName, every ServiceProfile that references it
+// is patched to the new name, and the original is deleted. If any step fails the completed steps are undone in reverse,
+// and the returned error also describes any step of the rollback that failed.
+// Service Profiles are bound to Onu and are not renamed
+func (l *LumiaOlt) RenameProfile(kind ProfileKind, oldName, newName string) error {
+	return l.RenameProfileCtx(context.Background(), kind, oldName, newName)
+}
+
+// RenameProfileCtx is RenameProfile with its requests bounded by ctx. The completed steps are undone even once ctx is done
+func (l *LumiaOlt) RenameProfileCtx(ctx context.Context, kind ProfileKind, oldName, newName string) error {
+	if !kind.IsSubProfile() || oldName == "" || newName == "" {
+		return ErrNotInput
+	}
+	table := string(kind)
+	im, err := l.GetAllProfilesCtx(ctx)
+	if err != nil {
+		return err
+	}
+	present := keyEntries(im.tableEntries(table), tableKeys[table])
+	cur, ok := present[oldName]
+	if !ok {
+		return fmt.Errorf("%w: %s %s", ErrNotExists, table, oldName)
+	}
+	if _, ok = present[newName]; ok {
+		return fmt.Errorf("%w: %s %s", ErrExists, table, newName)
+	}
+	p := reflect.New(cur.Type())
+	p.Elem().Set(cur)
+	if ovp, ok := p.Interface().(*OnuVlanProfile); ok {
+		ovp.Rules = im.onuVlanRulesOf(oldName)
+	}
+	np, err := copyProfile(p.Interface(), newName)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(np)
+	if err != nil {
+		return err
+	}
+	err = l.postProfile(ctx, table, newName, data)
+	if err != nil {
+		return fmt.Errorf("rename %s %s: %w", table, oldName, err)
+	}
+	var undo []func() error
+	undo = append(undo, func() error { return l.deleteProfile(context.Background(), table, newName) })
+
+	field := subProfileField(table)
+	for _, sp := range im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanServiceProfileTable.MsanServiceProfileEntry {
+		if reflect.ValueOf(sp).FieldByName(field).String() != oldName {
+			continue
+		}
+		spName := sp.Name
+		err = l.PatchServiceProfileCtx(ctx, spName, serviceProfileRef(spName, field, newName))
+		if err != nil {
+			return l.rollback(fmt.Errorf("rename %s %s: patch %s: %w", table, oldName, spName, err), undo)
+		}
+		undo = append(undo, func() error {
+			return l.PatchServiceProfile(spName, serviceProfileRef(spName, field, oldName))
+		})
+	}
+	err = l.deleteProfile(ctx, table, oldName)
+	if err != nil {
+		return l.rollback(fmt.Errorf("rename %s %s: %w", table, oldName, err), undo)
+	}
+	return nil
+}
+
+// rollback runs the undo steps in reverse, returning err along with any step that failed
+func (l *LumiaOlt) rollback(err error, undo []func() error) error {
+	var failed []string
+	for i := len(undo) - 1; i >= 0; i-- {
+		if uerr := undo[i](); uerr != nil {
+			failed = append(failed, uerr.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w (rollback failed: %s)", err, strings.Join(failed, "; "))
+	}
+	return err
+}
+
+// subProfileField returns the ServiceProfile field that references profiles of the table
+func subProfileField(table string) string {
+	for _, r := range subProfileRefs {
+		if r.table == table {
+			return r.field
+		}
+	}
+	return ""
+}
+
+// serviceProfileRef serializes a patch that sets one reference field of a ServiceProfile
+func serviceProfileRef(spName, field, name string) []byte {
+	t := reflect.TypeOf(ServiceProfile{})
+	nameField, _ := t.FieldByName("Name")
+	refField, _ := t.FieldByName(field)
+	data, _ := json.Marshal(map[string]string{
+		jsonName(nameField): spName,
+		jsonName(refField):  name,
+	})
+	return data
+}
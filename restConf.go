@@ -1,17 +1,13 @@
 package goPon
 
 import (
-	"bytes"
-	"crypto/tls"
-	"fmt"
-	"io/ioutil"
+	"context"
 	"net"
 	"net/http"
 	"time"
 )
 
 const (
-	responseOk       = "200 OK"
 	serviceProfiles  = "msanServiceProfileTable"
 	flowProfiles     = "msanServiceFlowProfileTable"
 	vlanProfiles     = "msanVlanProfileTable"
@@ -64,109 +60,57 @@ var endpointEntry = map[string]string{
 	onuProfiles:      "msanServicePortProfileEntry",
 }
 
-// checkHost checks if host accepts tcp connection on hardcoded https port
+// checkHost checks if host accepts tcp connection on the https port, 443 unless the host specifies one
 func CheckHost(host string, timeout int) (err error) {
 	to := time.Duration(timeout) * time.Second
-	_, err = net.DialTimeout("tcp", host+":443", to)
-	return
-}
-
-func RestGetProfiles(host string, ep string) ([]byte, error) {
-	reqUrl := fmt.Sprintf("https://%s/restconf/data/ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB/%s", host, ep)
-	fmt.Printf("------------\nGET Request: %s\n------------\n", reqUrl)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
-	req, err := http.NewRequest("GET", reqUrl, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Cookie", auth)
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	addr := host
+	if _, _, e := net.SplitHostPort(host); e != nil {
+		addr = net.JoinHostPort(host, "443")
 	}
-	defer resp.Body.Close()
-	raw, err := ioutil.ReadAll(resp.Body)
+	conn, err := net.DialTimeout("tcp", addr, to)
 	if err != nil {
-		return nil, err
+		return
 	}
-	return raw, nil
+	return conn.Close()
 }
 
-// host is ip address, ep is endpoint, epi is endpoint [*]unit of entry, name is profile name
-// could return http Response directly
-func RestPostProfile(host, ep, name string, data []byte) (string, error) {
-	reqUrl := fmt.Sprintf("https://%s/restconf/data/ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB/%s/%s=%s", host, ep, endpointEntry[ep], name)
-	fmt.Printf("------------\nPOST Request: %s\n------------\n", reqUrl)
-	//fmt.Println(string(data))
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
-	req, err := http.NewRequest("POST", reqUrl, bytes.NewBuffer(data))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Cookie", auth)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println(string(resp.Status))
-		return "", err
+// defaultRestconfTransport is shared by the package-level Rest helpers so connections are pooled between calls
+var defaultRestconfTransport = newRestconfTransport(InsecureTLSConfig())
+
+// restconfClientFor returns a lightweight client for the package-level Rest helpers
+func restconfClientFor(host, auth string) *RestconfClient {
+	un, pw := parseAuth(auth)
+	c := &RestconfClient{
+		Host:        host,
+		Credentials: NewCredentials(un, pw),
+		Timeout:     DefaultRestconfTimeout,
+		Verbose:     true,
+		client:      &http.Client{Transport: defaultRestconfTransport},
 	}
-	defer resp.Body.Close()
-	//fmt.Println(string(resp.Status))
+	return c
+}
 
-	return string(resp.Status), nil
+// RestGetProfiles performs a Get request to the endpoint table using the supplied session cookie
+func RestGetProfiles(host, auth, ep string) ([]byte, error) {
+	return restconfClientFor(host, auth).Get(context.Background(), ep)
 }
 
-func RestPatchProfile(host, ep, name string, data []byte) (string, error) {
-	reqUrl := fmt.Sprintf("https://%s/restconf/data/ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB/%s/%s=%s", host, ep, endpointEntry[ep], name)
-	fmt.Printf("------------\nPATCH Request: %s\n------------\n", reqUrl)
-	//fmt.Println(string(data))
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
-	req, err := http.NewRequest("PATCH", reqUrl, bytes.NewBuffer(data))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Cookie", auth)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		//fmt.Println(string(resp.Status))
-		return "", err
-	}
-	defer resp.Body.Close()
-	//fmt.Println(string(resp.Status))
+// host is ip address, auth is the session cookie, ep is endpoint, name is the Url encoded entry key
+// returns the Http status, and a *RestconfError if the status is not 2xx
+func RestPostProfile(host, auth, ep, name string, data []byte) (string, error) {
+	c := restconfClientFor(host, auth)
+	status, _, err := c.do(context.Background(), http.MethodPost, c.entryUrl(ep, name), data)
+	return status, err
+}
 
-	return string(resp.Status), nil
+func RestPatchProfile(host, auth, ep, name string, data []byte) (string, error) {
+	c := restconfClientFor(host, auth)
+	status, _, err := c.do(context.Background(), http.MethodPatch, c.entryUrl(ep, name), data)
+	return status, err
 }
 
-// returning http Response requires the profileManager to import HTTP
-func RestDeleteProfile(host string, ep string, name string) (string, error) {
-	reqUrl := fmt.Sprintf("https://%s/restconf/data/ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB/%s/%s=%s", host, ep, endpointEntry[ep], name)
-	fmt.Printf("------------\nDELETE Request: %s\n------------\n", reqUrl)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{Transport: tr}
-	req, err := http.NewRequest("DELETE", reqUrl, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Cookie", auth)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		//fmt.Println(resp.Status)
-		return resp.Status, err
-	}
-	defer resp.Body.Close()
-	//fmt.Println(string(resp.Status))
-	return resp.Status, nil
+func RestDeleteProfile(host, auth, ep, name string) (string, error) {
+	c := restconfClientFor(host, auth)
+	status, _, err := c.do(context.Background(), http.MethodDelete, c.entryUrl(ep, name), nil)
+	return status, err
 }
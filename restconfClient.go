@@ -0,0 +1,231 @@
+package goPon
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRestconfTimeout is the deadline applied to each request unless changed on the client
+	DefaultRestconfTimeout = 30 * time.Second
+	restconfRoot           = "restconf/data/ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB"
+)
+
+// RestconfClient holds a pooled connection to the Restconf server of a single OLT.
+// It is safe for concurrent use once configured
+type RestconfClient struct {
+	Host        string        // ip address or domain name, optionally with :port
+	Credentials *Credentials  // login sent as the session cookie
+	Timeout     time.Duration // per-request deadline, 0 relies on the caller's context only
+	Verbose     bool          // print each request as it is made
+	client      *http.Client
+}
+
+// NewRestconfClient returns a client for the supplied Host. Certificates are not verified by default,
+// matching the self-signed certificate shipped on the OLT; see SetTLSConfig, LoadCABundle and PinCertificates
+func NewRestconfClient(host string, cred *Credentials) *RestconfClient {
+	if cred == nil {
+		cred = DefaultCredentials()
+	}
+	c := &RestconfClient{
+		Host:        host,
+		Credentials: cred,
+		Timeout:     DefaultRestconfTimeout,
+		Verbose:     true,
+	}
+	c.SetTLSConfig(InsecureTLSConfig())
+	return c
+}
+
+// InsecureTLSConfig returns a TLS config that accepts any server certificate
+func InsecureTLSConfig() *tls.Config {
+	return &tls.Config{InsecureSkipVerify: true}
+}
+
+// newRestconfTransport creates the pooled transport shared by all requests of a client
+func newRestconfTransport(cfg *tls.Config) *http.Transport {
+	return &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     cfg,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        16,
+		MaxIdleConnsPerHost: 8,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// SetTLSConfig replaces the TLS settings of the client, closing any pooled connections
+func (c *RestconfClient) SetTLSConfig(cfg *tls.Config) {
+	if cfg == nil {
+		cfg = InsecureTLSConfig()
+	}
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+	}
+	c.client = &http.Client{Transport: newRestconfTransport(cfg)}
+}
+
+// SetInsecure disables verification of the server certificate
+func (c *RestconfClient) SetInsecure() {
+	c.SetTLSConfig(InsecureTLSConfig())
+}
+
+// LoadCABundle verifies the server certificate against the PEM encoded certificates in the supplied file
+func (c *RestconfClient) LoadCABundle(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	pem, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("%w: no certificates in %s", ErrNotInput, path)
+	}
+	c.SetTLSConfig(&tls.Config{RootCAs: pool})
+	return nil
+}
+
+// PinCertificates accepts only a server whose leaf certificate matches one of the supplied
+// SHA-256 fingerprints (hex, colons optional). The certificate chain itself is not verified
+func (c *RestconfClient) PinCertificates(fingerprints ...string) error {
+	if len(fingerprints) < 1 {
+		return ErrNotInput
+	}
+	pins := make(map[string]bool)
+	for _, fp := range fingerprints {
+		fp = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fp), ":", ""))
+		if len(fp) != sha256.Size*2 {
+			return fmt.Errorf("%w: %s", ErrNotInput, fp)
+		}
+		pins[fp] = true
+	}
+	cfg := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) < 1 {
+				return ErrNotAuthorized
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !pins[hex.EncodeToString(sum[:])] {
+				return fmt.Errorf("certificate %x is not pinned", sum)
+			}
+			return nil
+		},
+	}
+	c.SetTLSConfig(cfg)
+	return nil
+}
+
+// CloseIdleConnections releases the pooled connections of the client
+func (c *RestconfClient) CloseIdleConnections() {
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+	}
+}
+
+// tableUrl returns the Url of an endpoint table
+func (c *RestconfClient) tableUrl(ep string) string {
+	return fmt.Sprintf("https://%s/%s/%s", c.Host, restconfRoot, ep)
+}
+
+// entryUrl returns the Url of a single entry in an endpoint table, name must already be Url encoded
+func (c *RestconfClient) entryUrl(ep, name string) string {
+	return fmt.Sprintf("https://%s/%s/%s/%s=%s", c.Host, restconfRoot, ep, endpointEntry[ep], name)
+}
+
+// do performs a single request bounded by the client Timeout. A failure to complete the exchange is returned
+// as a *RequestError, and a response outside the 2xx range as a *RestconfError along with its status
+func (c *RestconfClient) do(ctx context.Context, method, reqUrl string, data []byte) (string, []byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	// the client is only read here, so concurrent requests do not race; one not made by NewRestconfClient
+	// falls back to the pooled insecure transport and the default login
+	client := c.client
+	if client == nil {
+		client = &http.Client{Transport: defaultRestconfTransport}
+	}
+	cred := c.Credentials
+	if cred == nil {
+		cred = DefaultCredentials()
+	}
+	if c.Verbose {
+		fmt.Printf("------------\n%s Request: %s\n------------\n", method, reqUrl)
+	}
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, body)
+	if err != nil {
+		return "", nil, newRequestError(method, reqUrl, err)
+	}
+	req.Header.Set("Cookie", cred.Cookie())
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// resp is always nil here, only the error is safe to use
+		return "", nil, newRequestError(method, reqUrl, err)
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Status, nil, newRequestError(method, reqUrl, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.Status, raw, newRestconfError(resp.StatusCode, resp.Status, raw)
+	}
+	return resp.Status, raw, nil
+}
+
+// Get performs a Get request to the endpoint table and returns the raw json
+func (c *RestconfClient) Get(ctx context.Context, ep string) ([]byte, error) {
+	_, raw, err := c.do(ctx, http.MethodGet, c.tableUrl(ep), nil)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Post creates the named entry in the endpoint table
+func (c *RestconfClient) Post(ctx context.Context, ep, name string, data []byte) error {
+	_, _, err := c.do(ctx, http.MethodPost, c.entryUrl(ep, name), data)
+	return err
+}
+
+// Patch merges data into the named entry of the endpoint table
+func (c *RestconfClient) Patch(ctx context.Context, ep, name string, data []byte) error {
+	_, _, err := c.do(ctx, http.MethodPatch, c.entryUrl(ep, name), data)
+	return err
+}
+
+// Delete removes the named entry from the endpoint table
+func (c *RestconfClient) Delete(ctx context.Context, ep, name string) error {
+	_, _, err := c.do(ctx, http.MethodDelete, c.entryUrl(ep, name), nil)
+	return err
+}
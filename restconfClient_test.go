@@ -0,0 +1,121 @@
+package goPon
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// requestErrorCase is a server that fails every exchange in one way, and how the cause is recognised
+type requestErrorCase struct {
+	name  string
+	setup func(t *testing.T) *RestconfClient
+	cause func(err error) bool
+}
+
+func TestRestconfClientRequestError(t *testing.T) {
+	cases := []requestErrorCase{
+		{
+			name: "connection refused",
+			setup: func(t *testing.T) *RestconfClient {
+				srv := httptest.NewTLSServer(http.NotFoundHandler())
+				host := srv.Listener.Addr().String()
+				// nothing listens on the port once the server is closed
+				srv.Close()
+				return newTestClient(host)
+			},
+			cause: func(err error) bool { return errors.Is(err, syscall.ECONNREFUSED) },
+		},
+		{
+			name: "tls failure",
+			setup: func(t *testing.T) *RestconfClient {
+				srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+				// the handshakes refused by the client are not worth logging
+				srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+				srv.StartTLS()
+				t.Cleanup(srv.Close)
+				c := newTestClient(srv.Listener.Addr().String())
+				// the certificate of the test server is not signed by any system root
+				c.SetTLSConfig(&tls.Config{})
+				return c
+			},
+			cause: func(err error) bool {
+				var uae x509.UnknownAuthorityError
+				return errors.As(err, &uae)
+			},
+		},
+		{
+			name: "truncated body",
+			setup: func(t *testing.T) *RestconfClient {
+				srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Length", "1024")
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte(`{"ISKRATEL-MSAN-MIB:ISKRATEL-MSAN-MIB":`))
+				}))
+				t.Cleanup(srv.Close)
+				return newTestClient(srv.Listener.Addr().String())
+			},
+			cause: func(err error) bool { return errors.Is(err, io.ErrUnexpectedEOF) },
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.setup(t)
+			defer c.CloseIdleConnections()
+			for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete} {
+				wantUrl, err := callRestconf(c, method)
+				var re *RequestError
+				if !errors.As(err, &re) {
+					t.Fatalf("%s: got %T %v, want *RequestError", method, err, err)
+				}
+				if re.Method != method {
+					t.Errorf("%s: Method = %q", method, re.Method)
+				}
+				if re.Url != wantUrl {
+					t.Errorf("%s: Url = %q, want %q", method, re.Url, wantUrl)
+				}
+				if !tc.cause(err) {
+					t.Errorf("%s: cause %T %v not recognised through errors.Is or errors.As", method, re.Err, re.Err)
+				}
+				var rce *RestconfError
+				if errors.As(err, &rce) {
+					t.Errorf("%s: transport failure reported as a RestconfError", method)
+				}
+				if !strings.Contains(err.Error(), method+" "+wantUrl) {
+					t.Errorf("%s: message %q does not name the request", method, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// newTestClient returns a quiet client for a test server at host
+func newTestClient(host string) *RestconfClient {
+	c := NewRestconfClient(host, DefaultCredentials())
+	c.Verbose = false
+	return c
+}
+
+// callRestconf makes one request with the helper of the method, returning the Url it should carry and its error
+func callRestconf(c *RestconfClient, method string) (string, error) {
+	ctx := context.Background()
+	switch method {
+	case http.MethodGet:
+		_, err := c.Get(ctx, serviceProfiles)
+		return c.tableUrl(serviceProfiles), err
+	case http.MethodPost:
+		return c.entryUrl(serviceProfiles, "TEST"), c.Post(ctx, serviceProfiles, "TEST", []byte(`{}`))
+	case http.MethodPatch:
+		return c.entryUrl(serviceProfiles, "TEST"), c.Patch(ctx, serviceProfiles, "TEST", []byte(`{}`))
+	}
+	return c.entryUrl(serviceProfiles, "TEST"), c.Delete(ctx, serviceProfiles, "TEST")
+}
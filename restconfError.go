@@ -0,0 +1,122 @@
+package goPon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RequestError is returned when a request to the OLT fails before a complete response is received,
+// such as a refused connection, a TLS failure, an expired deadline or a truncated body
+type RequestError struct {
+	Method string
+	Url    string
+	Err    error
+}
+
+// newRequestError wraps the cause of a failed request, removing the url.Error layer added by net/http
+// since the method and Url are already carried
+func newRequestError(method, reqUrl string, err error) *RequestError {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+	return &RequestError{Method: method, Url: reqUrl, Err: err}
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Method, e.Url, e.Err)
+}
+
+// Unwrap exposes the cause to errors.Is and errors.As, for example context.DeadlineExceeded or an x509.UnknownAuthorityError
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// RestconfError is the RFC 8040 "ietf-restconf:errors" payload returned by the OLT alongside a non-2xx status.
+// When the server reports several errors the first is held in the struct and the rest in Additional
+type RestconfError struct {
+	StatusCode   int              `json:"-"`
+	Status       string           `json:"-"`
+	ErrorType    string           `json:"error-type"`
+	ErrorTag     string           `json:"error-tag"`
+	ErrorAppTag  string           `json:"error-app-tag"`
+	ErrorPath    string           `json:"error-path"`
+	ErrorMessage string           `json:"error-message"`
+	Additional   []*RestconfError `json:"-"`
+}
+
+// restconfErrors mirrors both the module-qualified and plain forms of the errors container
+type restconfErrors struct {
+	Qualified struct {
+		Error []*RestconfError `json:"error"`
+	} `json:"ietf-restconf:errors"`
+	Plain struct {
+		Error []*RestconfError `json:"error"`
+	} `json:"errors"`
+}
+
+// maxErrorBody limits how much of an undecodable response body is kept as the error message
+const maxErrorBody = 256
+
+// newRestconfError decodes the body of a failed response. A body that is not a Restconf error payload
+// is kept, trimmed, as the ErrorMessage so the cause is never thrown away
+func newRestconfError(code int, status string, body []byte) *RestconfError {
+	var re restconfErrors
+	var list []*RestconfError
+	if json.Unmarshal(body, &re) == nil {
+		list = append(re.Qualified.Error, re.Plain.Error...)
+	}
+	if len(list) < 1 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > maxErrorBody {
+			msg = msg[:maxErrorBody] + "..."
+		}
+		list = append(list, &RestconfError{ErrorMessage: msg})
+	}
+	e := list[0]
+	e.Additional = list[1:]
+	e.StatusCode = code
+	e.Status = status
+	for _, a := range e.Additional {
+		a.StatusCode = code
+		a.Status = status
+	}
+	return e
+}
+
+func (e *RestconfError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "restconf: %s", e.Status)
+	if e.ErrorType != "" || e.ErrorTag != "" {
+		fmt.Fprintf(&b, " [%s/%s]", e.ErrorType, e.ErrorTag)
+	}
+	if e.ErrorPath != "" {
+		fmt.Fprintf(&b, " at %s", e.ErrorPath)
+	}
+	if e.ErrorMessage != "" {
+		fmt.Fprintf(&b, ": %s", e.ErrorMessage)
+	}
+	if len(e.Additional) > 0 {
+		fmt.Fprintf(&b, " (+%d more)", len(e.Additional))
+	}
+	return b.String()
+}
+
+// Is allows errors.Is to match the package errors a RestconfError represents.
+// Every RestconfError is ErrNotStatusOk; the error-tag further maps onto ErrExists, ErrNotExists and ErrInUse
+func (e *RestconfError) Is(target error) bool {
+	switch target {
+	case ErrNotStatusOk:
+		return true
+	case ErrExists:
+		return e.ErrorTag == "data-exists"
+	case ErrNotExists:
+		return e.ErrorTag == "data-missing" || e.StatusCode == 404
+	case ErrInUse:
+		return e.ErrorTag == "in-use"
+	}
+	return false
+}
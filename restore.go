@@ -0,0 +1,189 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"text/tabwriter"
+)
+
+// kinds of RestoreResult
+const (
+	RestoreCreated = "created"
+	RestoreSkipped = "skipped"
+	RestoreFailed  = "failed"
+)
+
+// restoreOrder lists the tables replayed by RestoreSnapshot so that every reference exists before it is used:
+// sub-profiles, then Service Profiles, then Onu Configs, then the Service Profile bindings of each Onu.
+// Onu Vlan Rules are posted with their Onu Vlan Profile, the Blacklist and Onu Info are read-only
+var restoreOrder = []string{
+	vlanProfiles,
+	flowProfiles,
+	securityProfiles,
+	igmpProfiles,
+	l2cpProfiles,
+	onuFlowProfiles,
+	onuTcontProfiles,
+	onuVlanProfiles,
+	onuIgmpProfiles,
+	serviceProfiles,
+	onuConfig,
+	onuProfiles,
+}
+
+// RestoreResult is the outcome of replaying a single entry
+type RestoreResult struct {
+	Table  string `json:"table"`
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Err    error  `json:"-"`
+}
+
+// RestoreReport lists the outcome of every entry replayed by RestoreSnapshot, in the order they were sent
+type RestoreReport struct {
+	Entry []*RestoreResult
+}
+
+// Failed returns the entries that could not be restored
+func (r *RestoreReport) Failed() []*RestoreResult {
+	var list []*RestoreResult
+	for _, e := range r.Entry {
+		if e.Status == RestoreFailed {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// RestoreSnapshot replays the profiles, Onu Configs and Service Profile bindings of a Snapshot onto l.Host in dependency order.
+// Entries whose key already exists on l.Host are skipped rather than overwritten.
+// A failed entry does not stop the restore, the report lists every outcome and the returned error wraps the first failure
+func (l *LumiaOlt) RestoreSnapshot(s *Snapshot) (*RestoreReport, error) {
+	return l.RestoreSnapshotCtx(context.Background(), s)
+}
+
+// RestoreSnapshotCtx is RestoreSnapshot with its requests bounded by ctx
+func (l *LumiaOlt) RestoreSnapshotCtx(ctx context.Context, s *Snapshot) (*RestoreReport, error) {
+	if s == nil || s.Data == nil {
+		return nil, ErrNotInput
+	}
+	existing, err := l.GetAllProfilesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &RestoreReport{}
+	var first error
+	for _, ep := range restoreOrder {
+		keys := tableKeys[ep]
+		present := keyEntries(existing.tableEntries(ep), keys)
+		entries := s.Data.tableEntries(ep)
+		for i := 0; i < entries.Len(); i++ {
+			// work on a copy so the Snapshot is left as it was loaded
+			e := reflect.New(entries.Type().Elem())
+			e.Elem().Set(entries.Index(i))
+			key := entryKey(e.Elem(), keys)
+			result := &RestoreResult{Table: ep, Key: key, Status: RestoreCreated}
+			report.Entry = append(report.Entry, result)
+			if _, ok := present[key]; ok {
+				result.Status = RestoreSkipped
+				continue
+			}
+			err = l.restoreEntry(ctx, ep, e.Interface(), s.Data)
+			if errors.Is(err, ErrExists) {
+				result.Status = RestoreSkipped
+				continue
+			}
+			if err != nil {
+				result.Status = RestoreFailed
+				result.Err = err
+				if first == nil {
+					first = fmt.Errorf("%s %s: %w", ep, key, err)
+				}
+			}
+		}
+	}
+	return report, first
+}
+
+// restoreEntry sends a single entry to l.Host with the request its table expects
+func (l *LumiaOlt) restoreEntry(ctx context.Context, ep string, v interface{}, src *IskratelMsan) error {
+	switch p := v.(type) {
+	case *OnuConfig:
+		return l.AuthorizeOnuOverrideCtx(ctx, p)
+	case *OnuProfile:
+		return l.PostOnuProfileCtx(ctx, p)
+	case *OnuVlanProfile:
+		p.Rules = src.onuVlanRulesOf(p.Name)
+	}
+	// Usage is maintained by the OLT and is posted as unset like a newly constructed profile
+	if u := reflect.ValueOf(v).Elem().FieldByName("Usage"); u.IsValid() {
+		u.SetInt(0)
+	}
+	name := reflect.ValueOf(v).Elem().FieldByName("Name").String()
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return l.postRecorded(ctx, ep, name, data)
+}
+
+// onuVlanRulesOf collects the rules of the named OnuVlanProfile from the rule table
+func (im *IskratelMsan) onuVlanRulesOf(name string) *OnuVlanRuleList {
+	list := &OnuVlanRuleList{}
+	rules := im.ISKRATELMSANMIB.ISKRATELMSANMIB.MsanOnuVlanProfileRuleTable.MsanOnuVlanProfileRuleEntry
+	for i := range rules {
+		if rules[i].Name == name {
+			r := rules[i]
+			list.Entry = append(list.Entry, &r)
+		}
+	}
+	return list
+}
+
+// tableEntries returns the slice of entries of the table named by ep, or an empty value if there is no such table
+func (im *IskratelMsan) tableEntries(ep string) reflect.Value {
+	v := reflect.ValueOf(im.ISKRATELMSANMIB.ISKRATELMSANMIB)
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == ep {
+			return v.Field(i).Field(0)
+		}
+	}
+	return reflect.ValueOf([]struct{}{})
+}
+
+var RestoreReportHeaders = []string{
+	"Table",
+	"Key",
+	"Status",
+	"Error",
+}
+
+// Tabwrite displays the outcome of a restore in organized columns
+func (r *RestoreReport) Tabwrite() {
+	fmt.Println("|| Restore Report ||")
+	tw := new(tabwriter.Writer).Init(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, v := range RestoreReportHeaders {
+		fmt.Fprintf(tw, "%v\t", v)
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, v := range RestoreReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	for _, e := range r.Entry {
+		var errString string
+		if e.Err != nil {
+			errString = e.Err.Error()
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t\n", e.Table, e.Key, e.Status, errString)
+	}
+	for _, v := range RestoreReportHeaders {
+		fmt.Fprintf(tw, "%v\t", fs(v))
+	}
+	fmt.Fprintf(tw, "\n")
+	tw.Flush()
+}
@@ -76,10 +76,10 @@ func (p *SecurityProfile) Copy(newName string) (*SecurityProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 var SecStmCtlList = []string{
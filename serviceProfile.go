@@ -142,10 +142,10 @@ func (sp *ServiceProfile) Copy(newName string) (*ServiceProfile, error) {
 		return nil, ErrExists
 	}
 
-	nsp := sp
+	nsp := *sp
 	nsp.Name = newName
 	nsp.Usage = 2
-	return nsp, nil
+	return &nsp, nil
 }
 
 // ServiceProfileEssentialHeaders ensure correct order of entries is maintained for Tabwriter
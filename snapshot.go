@@ -0,0 +1,216 @@
+package goPon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// SnapshotVersion is the format version written with every Snapshot
+const SnapshotVersion = 1
+
+// snapshotEndpoints are the tables retrieved for a complete tree, Endpoints plus the read-only Onu Info
+var snapshotEndpoints = append(append([]string(nil), Endpoints...), onuInfo)
+
+// Snapshot is a complete IskratelMsan tree retrieved from a Host at a point in time
+type Snapshot struct {
+	Version   int           `json:"version"`
+	Host      string        `json:"host"`
+	Timestamp time.Time     `json:"timestamp"`
+	Data      *IskratelMsan `json:"data"`
+}
+
+// setTable replaces the table named by ep with the same table from src
+func (im *IskratelMsan) setTable(ep string, src *IskratelMsan) error {
+	d := &im.ISKRATELMSANMIB.ISKRATELMSANMIB
+	s := &src.ISKRATELMSANMIB.ISKRATELMSANMIB
+	switch ep {
+	case serviceProfiles:
+		d.MsanServiceProfileTable = s.MsanServiceProfileTable
+	case flowProfiles:
+		d.MsanServiceFlowProfileTable = s.MsanServiceFlowProfileTable
+	case vlanProfiles:
+		d.MsanVlanProfileTable = s.MsanVlanProfileTable
+	case igmpProfiles:
+		d.MsanMulticastProfileTable = s.MsanMulticastProfileTable
+	case securityProfiles:
+		d.MsanSecurityProfileTable = s.MsanSecurityProfileTable
+	case onuFlowProfiles:
+		d.MsanOnuFlowProfileTable = s.MsanOnuFlowProfileTable
+	case onuTcontProfiles:
+		d.MsanOnuTcontProfileTable = s.MsanOnuTcontProfileTable
+	case onuVlanProfiles:
+		d.MsanOnuVlanProfileTable = s.MsanOnuVlanProfileTable
+	case onuVlanRules:
+		d.MsanOnuVlanProfileRuleTable = s.MsanOnuVlanProfileRuleTable
+	case onuIgmpProfiles:
+		d.MsanOnuMulticastProfileTable = s.MsanOnuMulticastProfileTable
+	case l2cpProfiles:
+		d.MsanL2CpProfileTable = s.MsanL2CpProfileTable
+	case onuBlacklist:
+		d.MsanOnuBlackListTable = s.MsanOnuBlackListTable
+	case onuConfig:
+		d.MsanOnuCfgTable = s.MsanOnuCfgTable
+	case onuInfo:
+		d.MsanOnuInfoTable = s.MsanOnuInfoTable
+	case onuProfiles:
+		d.MsanServicePortProfileTable = s.MsanServicePortProfileTable
+	default:
+		return fmt.Errorf("%w: %s", ErrNotField, ep)
+	}
+	return nil
+}
+
+// updateCurrent decodes the response of a Get on the endpoint table ep and merges that table into Current,
+// leaving Cache as it is: only a full refresh by GetIskratelMsan moves Current to Cache.
+// Current takes its own copy of the entries, so the caller may hand out and change those of the tree returned
+func (l *LumiaOlt) updateCurrent(ep string, rawJson []byte) (*IskratelMsan, error) {
+	fresh := NewIskratelMsan()
+	err := json.Unmarshal(rawJson, fresh)
+	if err != nil {
+		return nil, err
+	}
+	l.treeMu.Lock()
+	defer l.treeMu.Unlock()
+	// the copy shares the slices of untouched tables with the previous Current, which may be Cache.
+	// No entry of Current is handed out, so those tables are only ever replaced as a whole
+	next := *l.Current
+	err = next.copyTable(ep, fresh)
+	if err != nil {
+		return nil, err
+	}
+	l.Current = &next
+	return fresh, nil
+}
+
+// copyTable replaces the table named by ep with a copy of the same table from src.
+// The entries of a decoded tree hold no references, so copying the slice leaves nothing shared with src
+func (im *IskratelMsan) copyTable(ep string, src *IskratelMsan) error {
+	err := im.setTable(ep, src)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(&im.ISKRATELMSANMIB.ISKRATELMSANMIB).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) != ep {
+			continue
+		}
+		entries := v.Field(i).Field(0)
+		if !entries.IsNil() {
+			entries.Set(reflect.AppendSlice(reflect.MakeSlice(entries.Type(), 0, entries.Len()), entries))
+		}
+	}
+	return nil
+}
+
+// GetAllProfiles retrieves every table of the OLT concurrently and returns them merged into a single tree.
+// Current and Cache are not changed, see GetIskratelMsan
+func (l *LumiaOlt) GetAllProfiles() (*IskratelMsan, error) {
+	return l.GetAllProfilesCtx(context.Background())
+}
+
+// GetAllProfilesCtx is GetAllProfiles with its requests bounded by ctx
+func (l *LumiaOlt) GetAllProfilesCtx(ctx context.Context) (*IskratelMsan, error) {
+	type result struct {
+		tree *IskratelMsan
+		err  error
+	}
+	results := make([]result, len(snapshotEndpoints))
+	var wg sync.WaitGroup
+	for i, ep := range snapshotEndpoints {
+		wg.Add(1)
+		go func(i int, ep string) {
+			defer wg.Done()
+			rawJson, err := l.Client.Get(ctx, ep)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].tree = NewIskratelMsan()
+			results[i].err = json.Unmarshal(rawJson, results[i].tree)
+		}(i, ep)
+	}
+	wg.Wait()
+	im := NewIskratelMsan()
+	for i, ep := range snapshotEndpoints {
+		if results[i].err != nil {
+			return nil, fmt.Errorf("%s: %w", ep, results[i].err)
+		}
+		err := im.setTable(ep, results[i].tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return im, nil
+}
+
+// GetIskratelMsan retrieves the complete tree of the OLT, sets it as Current with the previous tree as Cache,
+// and returns it as a Snapshot stamped with the Host and the time the retrieval started
+func (l *LumiaOlt) GetIskratelMsan() (*Snapshot, error) {
+	start := time.Now()
+	im, err := l.GetAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	// Current keeps its own copy, the Snapshot is the caller's to change
+	kept := NewIskratelMsan()
+	for _, ep := range snapshotEndpoints {
+		err = kept.copyTable(ep, im)
+		if err != nil {
+			return nil, err
+		}
+	}
+	l.treeMu.Lock()
+	l.Cache = l.Current
+	l.Current = kept
+	l.treeMu.Unlock()
+	s := &Snapshot{
+		Version:   SnapshotVersion,
+		Host:      l.Host,
+		Timestamp: start,
+		Data:      im,
+	}
+	return s, nil
+}
+
+// SaveSnapshot retrieves the complete tree of the OLT with GetIskratelMsan and writes it to path as versioned Json
+func (l *LumiaOlt) SaveSnapshot(path string) (*Snapshot, error) {
+	s, err := l.GetIskratelMsan()
+	if err != nil {
+		return nil, err
+	}
+	return s, s.WriteFile(path)
+}
+
+// WriteFile serializes the Snapshot as indented Json to path
+func (s *Snapshot) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadSnapshot reads a Snapshot written by SaveSnapshot, rejecting files of an unknown version
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		return nil, err
+	}
+	if s.Version < 1 || s.Version > SnapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrNotVersion, s.Version)
+	}
+	if s.Data == nil {
+		s.Data = NewIskratelMsan()
+	}
+	return s, nil
+}
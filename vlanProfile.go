@@ -62,10 +62,10 @@ func (p *VlanProfile) Copy(newName string) (*VlanProfile, error) {
 	if p.Name == newName {
 		return nil, ErrExists
 	}
-	np := p
+	np := *p
 	np.Name = newName
 	np.Usage = 2
-	return np, nil
+	return &np, nil
 }
 
 // GetCVid returns the values set as Customer VLAN ID in the VlanProfile